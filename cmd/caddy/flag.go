@@ -3,13 +3,23 @@ package main
 import (
 	"flag"
 	"strings"
+	"time"
 
 	"github.com/caddyserver/ingress/pkg/store"
 )
 
 func parseFlags() store.Options {
-	var namespace string
-	flag.StringVar(&namespace, "namespace", "", "the namespace that you would like to observe kubernetes ingress resources in.")
+	var namespaces string
+	flag.StringVar(&namespaces, "namespace", "", "comma-separated list of namespaces that you would like to observe kubernetes ingress resources in.")
+
+	var namespaceSelector string
+	flag.StringVar(&namespaceSelector, "namespace-selector", "", "only observe kubernetes ingress resources in namespaces matching this label selector.")
+
+	var ingressLabelSelector string
+	flag.StringVar(&ingressLabelSelector, "ingress-label-selector", "", "only observe kubernetes ingress resources matching this label selector, overridden by the config map.")
+
+	var defaultBackendService string
+	flag.StringVar(&defaultBackendService, "default-backend-service", "", "service serving requests no ingress matches, as namespace/name:port, overridden by the config map.")
 
 	var className string
 	flag.StringVar(&className, "class-name", "caddy", "class name of the ingress controller")
@@ -17,27 +27,73 @@ func parseFlags() store.Options {
 	var classNameRequired bool
 	flag.BoolVar(&classNameRequired, "class-name-required", false, "only allow ingress resources with a matching ingress class name")
 
+	var controllerName string
+	flag.StringVar(&controllerName, "controller-name", "caddy.io/ingress-controller", "defines the spec.controller of the IngressClass resources handled by the ingress controller")
+
 	var configMapName string
 	flag.StringVar(&configMapName, "config-map", "", "defines the config map name from where to load global options")
 
 	var leaseID string
 	flag.StringVar(&leaseID, "lease-id", "", "defines the id of this instance for certmagic lock")
 
+	var leaderElectionID string
+	flag.StringVar(&leaderElectionID, "leader-election-id", "caddy-ingress-controller-leader", "defines the name of the lease used to elect the instance updating ingress statuses")
+
 	var verbose bool
 	flag.BoolVar(&verbose, "verbose", false, "set the log level to debug")
 
 	var pluginsOrder string
 	flag.StringVar(&pluginsOrder, "plugins-order", "", "defines the order plugins should be used")
 
+	var maxRetries int
+	flag.IntVar(&maxRetries, "max-retries", 5, "defines how many times a failed action is retried before being dropped")
+
+	var reloadDebounce time.Duration
+	flag.DurationVar(&reloadDebounce, "reload-debounce", time.Second, "defines how long to wait for other changes before reloading caddy")
+
+	var reloadMaxDelay time.Duration
+	flag.DurationVar(&reloadMaxDelay, "reload-max-delay", 10*time.Second, "defines the maximum delay between a change and the caddy reload")
+
+	var configHistorySize int
+	flag.IntVar(&configHistorySize, "config-history-size", 10, "defines how many applied caddy configs are kept for rollbacks (0 to disable)")
+
+	var configHistoryName string
+	flag.StringVar(&configHistoryName, "config-history-name", "caddy-ingress-controller-history", "defines the name of the secret storing applied caddy configs")
+
+	var stuckWorkerTimeout time.Duration
+	flag.DurationVar(&stuckWorkerTimeout, "stuck-worker-timeout", 5*time.Minute, "defines how long the worker can process a single change before failing the liveness probe (0 to disable)")
+
 	flag.Parse()
 
 	return store.Options{
-		WatchNamespace:    namespace,
-		ClassName:         className,
-		ClassNameRequired: classNameRequired,
-		ConfigMapName:     configMapName,
-		Verbose:           verbose,
-		LeaseID:           leaseID,
-		PluginsOrder:      strings.Split(pluginsOrder, ","),
+		WatchNamespaces:       splitList(namespaces),
+		NamespaceSelector:     namespaceSelector,
+		IngressLabelSelector:  ingressLabelSelector,
+		DefaultBackendService: defaultBackendService,
+		ClassName:             className,
+		ClassNameRequired:     classNameRequired,
+		ControllerName:        controllerName,
+		ConfigMapName:         configMapName,
+		Verbose:               verbose,
+		LeaseID:               leaseID,
+		LeaderElectionID:      leaderElectionID,
+		PluginsOrder:          strings.Split(pluginsOrder, ","),
+		MaxRetries:            maxRetries,
+		ReloadDebounce:        reloadDebounce,
+		ReloadMaxDelay:        reloadMaxDelay,
+		ConfigHistorySize:     configHistorySize,
+		ConfigHistoryName:     configHistoryName,
+		StuckWorkerTimeout:    stuckWorkerTimeout,
+	}
+}
+
+// splitList returns the non-empty values of a comma-separated list.
+func splitList(list string) []string {
+	var values []string
+	for _, v := range strings.Split(list, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
 	}
+	return values
 }
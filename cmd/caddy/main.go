@@ -9,7 +9,6 @@ import (
 	"github.com/caddyserver/ingress/internal/caddy"
 	"github.com/caddyserver/ingress/internal/controller"
 	"go.uber.org/zap"
-	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/client-go/kubernetes"
@@ -41,8 +40,7 @@ func main() {
 
 	logger := createLogger(cfg.Verbose)
 
-	if cfg.WatchNamespace == "" {
-		cfg.WatchNamespace = v1.NamespaceAll
+	if len(cfg.WatchNamespaces) == 0 && cfg.NamespaceSelector == "" {
 		logger.Warn("-namespace flag is unset, caddy ingress controller will monitor ingress resources in all namespaces.")
 	}
 
@@ -1,6 +1,9 @@
 package caddy
 
 import (
+	"errors"
+	"maps"
+
 	"github.com/caddyserver/ingress/pkg/converter"
 	"github.com/caddyserver/ingress/pkg/store"
 
@@ -11,16 +14,25 @@ import (
 
 type Converter struct{}
 
-func (c Converter) ConvertToCaddyConfig(store *store.Store) (any, error) {
+// ConvertToCaddyConfig runs all global plugins to generate the caddy config.
+// Errors attributed to a specific Ingress (converter.IngressError) do not stop the conversion,
+// they are returned along with a config that can still be loaded. Such an Ingress is served
+// with its routes from lastGoodRoutes, if any.
+func (c Converter) ConvertToCaddyConfig(store *store.Store, lastGoodRoutes converter.IngressRoutes) (any, error) {
 	cfg := converter.NewConfig()
+	maps.Copy(cfg.IngressRoutes, lastGoodRoutes)
 
+	var errs []error
 	for _, p := range converter.Plugins(store.Options.PluginsOrder) {
 		if m, ok := p.(converter.GlobalMiddleware); ok {
-			err := m.GlobalHandler(cfg, store)
+			ingErrs, err := converter.SplitIngressErrors(m.GlobalHandler(cfg, store))
 			if err != nil {
 				return cfg, err
 			}
+			for _, ingErr := range ingErrs {
+				errs = append(errs, ingErr)
+			}
 		}
 	}
-	return cfg, nil
+	return cfg, errors.Join(errs...)
 }
@@ -2,11 +2,18 @@ package caddy
 
 import (
 	"encoding/json"
+	"maps"
 	"os"
+	"slices"
+	"strings"
 	"testing"
 
+	"github.com/caddyserver/ingress/pkg/converter"
 	"github.com/caddyserver/ingress/pkg/store"
 	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 func TestConvertToCaddyConfig(t *testing.T) {
@@ -21,7 +28,7 @@ func TestConvertToCaddyConfig(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			cfg, err := Converter{}.ConvertToCaddyConfig(store.NewStore(store.Options{}, "", &store.PodInfo{}))
+			cfg, err := Converter{}.ConvertToCaddyConfig(store.NewStore(store.Options{}, "", &store.PodInfo{}), nil)
 			require.NoError(t, err)
 
 			cfgJSON, err := json.Marshal(cfg)
@@ -34,3 +41,197 @@ func TestConvertToCaddyConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestConvertIsolatesIngressErrors(t *testing.T) {
+	s := store.NewStore(store.Options{}, "", &store.PodInfo{})
+	good := createIngress("good", nil)
+	bad := createIngress("bad", nil)
+	s.AddIngress(good)
+	s.AddIngress(bad)
+
+	// both ingresses convert successfully
+	cfg, err := Converter{}.ConvertToCaddyConfig(s, nil)
+	require.NoError(t, err)
+	require.Len(t, cfg.(*converter.Config).GetHTTPServer().Routes, 2)
+	lastGoodRoutes := cfg.(*converter.Config).IngressRoutes
+	require.Len(t, lastGoodRoutes, 2)
+
+	// an invalid annotation only affects its own ingress, which keeps its last good routes
+	invalid := createIngress("bad", map[string]string{
+		"caddy.ingress.kubernetes.io/permanent-redirect":      "http://example.com",
+		"caddy.ingress.kubernetes.io/permanent-redirect-code": "502",
+	})
+	s.AddIngress(invalid)
+	cfg, err = Converter{}.ConvertToCaddyConfig(s, lastGoodRoutes)
+
+	ingErrs, other := converter.SplitIngressErrors(err)
+	require.NoError(t, other)
+	require.Len(t, ingErrs, 1)
+	require.Equal(t, "bad", ingErrs[0].Ingress.Name)
+	require.Equal(t, "ingress.redirect", ingErrs[0].Plugin)
+	require.Len(t, cfg.(*converter.Config).GetHTTPServer().Routes, 2)
+	require.Equal(t, lastGoodRoutes[bad.UID], cfg.(*converter.Config).IngressRoutes[bad.UID])
+
+	// the last good routes given to the conversion are left untouched
+	require.Len(t, lastGoodRoutes, 2)
+
+	// without last good routes, the ingress is skipped
+	cfg, err = Converter{}.ConvertToCaddyConfig(s, nil)
+	ingErrs, _ = converter.SplitIngressErrors(err)
+	require.Len(t, ingErrs, 1)
+	require.Len(t, cfg.(*converter.Config).GetHTTPServer().Routes, 1)
+	require.NotContains(t, cfg.(*converter.Config).IngressRoutes, bad.UID)
+
+	// routes of deleted ingresses are forgotten
+	s.PluckIngress(invalid)
+	cfg, err = Converter{}.ConvertToCaddyConfig(s, lastGoodRoutes)
+	require.NoError(t, err)
+	require.Len(t, cfg.(*converter.Config).GetHTTPServer().Routes, 1)
+	require.Equal(t, []types.UID{good.UID}, slices.Collect(maps.Keys(cfg.(*converter.Config).IngressRoutes)))
+}
+
+func TestConvertDefaultBackends(t *testing.T) {
+	s := store.NewStore(store.Options{DefaultBackendService: "fallback/global:8080"}, "", &store.PodInfo{})
+	s.ConfigMap.ExperimentalSmartSort = true
+
+	withDefault := createIngress("with-default", nil)
+	withDefault.Spec.DefaultBackend = &networkingv1.IngressBackend{
+		Service: &networkingv1.IngressServiceBackend{Name: "host-default", Port: networkingv1.ServiceBackendPort{Number: 80}},
+	}
+	catchAll := createIngress("catch-all", nil)
+	catchAll.Spec.Rules = nil
+	catchAll.Spec.DefaultBackend = &networkingv1.IngressBackend{
+		Service: &networkingv1.IngressServiceBackend{Name: "catch-all", Port: networkingv1.ServiceBackendPort{Number: 80}},
+	}
+	s.AddIngress(catchAll)
+	s.AddIngress(withDefault)
+	s.AddIngress(createIngress("other", nil))
+
+	cfg, err := Converter{}.ConvertToCaddyConfig(s, nil)
+	require.NoError(t, err)
+
+	var upstreams []string
+	for _, r := range cfg.(*converter.Config).GetHTTPServer().Routes {
+		var handler struct {
+			Upstreams []struct {
+				Dial string `json:"dial"`
+			} `json:"upstreams"`
+		}
+		require.NoError(t, json.Unmarshal(r.HandlersRaw[len(r.HandlersRaw)-1], &handler))
+		upstreams = append(upstreams, handler.Upstreams[0].Dial)
+	}
+
+	// routes of rules go first, then default backends of specific hosts, of any host and of the controller
+	require.Equal(t, []string{
+		"with-default.default.svc.cluster.local:80",
+		"other.default.svc.cluster.local:80",
+		"host-default.default.svc.cluster.local:80",
+		"catch-all.default.svc.cluster.local:80",
+		"global.fallback.svc.cluster.local:8080",
+	}, upstreams)
+}
+
+func TestConvertWildcardHostPrecedence(t *testing.T) {
+	for _, smartSort := range []bool{false, true} {
+		s := store.NewStore(store.Options{}, "", &store.PodInfo{})
+		s.ConfigMap.ExperimentalSmartSort = smartSort
+
+		anyHost := createIngress("any", nil)
+		anyHost.Spec.Rules[0].Host = ""
+		wildcard := createIngress("wildcard", nil)
+		wildcard.Spec.Rules[0].Host = "*.example.com"
+		wildcard.Spec.Rules[0].HTTP.Paths[0].Path = "/api"
+		s.AddIngress(anyHost)
+		s.AddIngress(wildcard)
+		s.AddIngress(createIngress("exact", nil))
+
+		cfg, err := Converter{}.ConvertToCaddyConfig(s, nil)
+		require.NoError(t, err)
+
+		var hosts []string
+		for _, r := range cfg.(*converter.Config).GetHTTPServer().Routes {
+			var host []string
+			if raw, ok := r.MatcherSetsRaw[0]["host"]; ok {
+				require.NoError(t, json.Unmarshal(raw, &host))
+			}
+			hosts = append(hosts, strings.Join(host, ","))
+		}
+
+		// exact hosts go first, whatever the order of ingresses and paths
+		require.Equal(t, []string{"exact.example.com", "*.example.com", ""}, hosts, "smart sort %v", smartSort)
+	}
+}
+
+func TestConvertCanary(t *testing.T) {
+	s := store.NewStore(store.Options{}, "", &store.PodInfo{})
+
+	canary := createIngress("canary", map[string]string{
+		"caddy.ingress.kubernetes.io/canary":        "true",
+		"caddy.ingress.kubernetes.io/canary-weight": "20",
+	})
+	canary.Spec.Rules[0].Host = "primary.example.com"
+	orphan := createIngress("orphan", map[string]string{
+		"caddy.ingress.kubernetes.io/canary":        "true",
+		"caddy.ingress.kubernetes.io/canary-weight": "20",
+	})
+	s.AddIngress(canary)
+	s.AddIngress(orphan)
+	s.AddIngress(createIngress("other", nil))
+	s.AddIngress(createIngress("primary", nil))
+
+	cfg, err := Converter{}.ConvertToCaddyConfig(s, nil)
+	ingErrs, err := converter.SplitIngressErrors(err)
+	require.NoError(t, err)
+	require.Len(t, ingErrs, 1)
+	require.Equal(t, "orphan", ingErrs[0].Ingress.Name)
+	require.EqualError(t, ingErrs[0].Err, "no primary Ingress for the host and path of this canary")
+
+	var upstreams []string
+	for _, r := range cfg.(*converter.Config).GetHTTPServer().Routes {
+		var handler struct {
+			Upstreams []struct {
+				Dial string `json:"dial"`
+			} `json:"upstreams"`
+		}
+		require.NoError(t, json.Unmarshal(r.HandlersRaw[len(r.HandlersRaw)-1], &handler))
+		upstreams = append(upstreams, handler.Upstreams[0].Dial)
+	}
+
+	// the canary goes right before its primary, the orphan canary is dropped
+	require.Equal(t, []string{
+		"other.default.svc.cluster.local:80",
+		"canary.default.svc.cluster.local:80",
+		"primary.default.svc.cluster.local:80",
+	}, upstreams)
+}
+
+func createIngress(name string, annotations map[string]string) *networkingv1.Ingress {
+	pathType := networkingv1.PathTypePrefix
+	return &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "default",
+			UID:         types.UID(name),
+			Annotations: annotations,
+		},
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{{
+				Host: name + ".example.com",
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{
+							Path:     "/",
+							PathType: &pathType,
+							Backend: networkingv1.IngressBackend{
+								Service: &networkingv1.IngressServiceBackend{
+									Name: name,
+									Port: networkingv1.ServiceBackendPort{Number: 80},
+								},
+							},
+						}},
+					},
+				},
+			}},
+		},
+	}
+}
@@ -0,0 +1,241 @@
+package global
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+	"github.com/caddyserver/ingress/pkg/converter"
+	v1 "k8s.io/api/networking/v1"
+)
+
+const (
+	canaryAnnotation              = "caddy.ingress.kubernetes.io/canary"
+	canaryWeightAnnotation        = "caddy.ingress.kubernetes.io/canary-weight"
+	canaryByHeaderAnnotation      = "caddy.ingress.kubernetes.io/canary-by-header"
+	canaryByHeaderValueAnnotation = "caddy.ingress.kubernetes.io/canary-by-header-value"
+	canaryByCookieAnnotation      = "caddy.ingress.kubernetes.io/canary-by-cookie"
+
+	// values of the canary header or cookie pinning a request to the canary or to the primary
+	canaryAlways = "always"
+	canaryNever  = "never"
+)
+
+// hexDigits are the digits of request UUIDs, used to split traffic by weight.
+const hexDigits = "0123456789abcdef"
+
+// canaryConfig is the traffic sent to a canary Ingress instead of its primary Ingress.
+type canaryConfig struct {
+	weight      int
+	header      string
+	headerValue string
+	cookie      string
+}
+
+// isCanary returns whether the ingress is the canary of another ingress.
+func isCanary(ing *v1.Ingress) bool {
+	return ing.Annotations[canaryAnnotation] == "true"
+}
+
+// parseCanary returns the canary config of an ingress from its annotations.
+func parseCanary(ing *v1.Ingress) (canaryConfig, error) {
+	cfg := canaryConfig{
+		header:      ing.Annotations[canaryByHeaderAnnotation],
+		headerValue: ing.Annotations[canaryByHeaderValueAnnotation],
+		cookie:      ing.Annotations[canaryByCookieAnnotation],
+	}
+
+	if w := ing.Annotations[canaryWeightAnnotation]; w != "" {
+		weight, err := strconv.Atoi(w)
+		if err != nil || weight < 0 || weight > 100 {
+			return cfg, fmt.Errorf("invalid %s %q, expected a percentage between 0 and 100", canaryWeightAnnotation, w)
+		}
+		cfg.weight = weight
+	}
+
+	if cfg.headerValue != "" && cfg.header == "" {
+		return cfg, fmt.Errorf("%s requires %s", canaryByHeaderValueAnnotation, canaryByHeaderAnnotation)
+	}
+	return cfg, nil
+}
+
+// matcherSets returns the matcher sets of the requests matching base that go to the canary.
+//
+// Like other canary implementations, the header takes precedence over the cookie, which takes
+// precedence over the weight. With a header value, only this value pins requests to the
+// canary, otherwise "always" and "never" pin requests to the canary or to the primary.
+func (c canaryConfig) matcherSets(base caddy.ModuleMap) []caddy.ModuleMap {
+	with := func(matchers map[string]any) caddy.ModuleMap {
+		set := maps.Clone(base)
+		for name, m := range matchers {
+			set[name] = caddyconfig.JSON(m, nil)
+		}
+		return set
+	}
+
+	var sets []caddy.ModuleMap
+	var pinnedToPrimary []caddy.ModuleMap
+
+	if c.header != "" {
+		value := c.headerValue
+		if value == "" {
+			value = canaryAlways
+			pinnedToPrimary = append(pinnedToPrimary, caddy.ModuleMap{
+				"header": caddyconfig.JSON(caddyhttp.MatchHeader{c.header: {canaryNever}}, nil),
+			})
+		}
+		sets = append(sets, with(map[string]any{
+			"header": caddyhttp.MatchHeader{c.header: {value}},
+		}))
+	}
+
+	if c.cookie != "" {
+		matchers := map[string]any{"header_regexp": cookieMatcher(c.cookie, canaryAlways)}
+		if len(pinnedToPrimary) > 0 {
+			matchers["not"] = caddyhttp.MatchNot{MatcherSetsRaw: slices.Clone(pinnedToPrimary)}
+		}
+		sets = append(sets, with(matchers))
+
+		pinnedToPrimary = append(pinnedToPrimary, caddy.ModuleMap{
+			"header_regexp": caddyconfig.JSON(cookieMatcher(c.cookie, canaryNever), nil),
+		})
+	}
+
+	if c.weight > 0 {
+		matchers := map[string]any{}
+		if c.weight < 100 {
+			matchers["vars_regexp"] = caddyhttp.MatchVarsRE{
+				"{http.request.uuid}": &caddyhttp.MatchRegexp{Pattern: weightPattern(c.weight)},
+			}
+		}
+		if len(pinnedToPrimary) > 0 {
+			matchers["not"] = caddyhttp.MatchNot{MatcherSetsRaw: pinnedToPrimary}
+		}
+		sets = append(sets, with(matchers))
+	}
+	return sets
+}
+
+// cookieMatcher returns a matcher of the requests with a cookie set to value.
+func cookieMatcher(name, value string) caddyhttp.MatchHeaderRE {
+	return caddyhttp.MatchHeaderRE{
+		"Cookie": &caddyhttp.MatchRegexp{
+			Pattern: `(^|;\s*)` + regexp.QuoteMeta(name) + "=" + regexp.QuoteMeta(value) + `(;|$)`,
+		},
+	}
+}
+
+// weightPattern returns a regular expression matching a percentage of request UUIDs.
+//
+// The first 2 hex digits of random UUIDs are a number between 0 and 255, the pattern
+// matches the ones lower than the weight of 256.
+func weightPattern(weight int) string {
+	n := int(math.Round(float64(weight) * 256 / 100))
+	high, low := n/16, n%16
+
+	var alternatives []string
+	if high > 0 {
+		alternatives = append(alternatives, "["+hexDigits[:high]+"][0-9a-f]")
+	}
+	if low > 0 {
+		alternatives = append(alternatives, hexDigits[high:high+1]+"["+hexDigits[:low]+"]")
+	}
+	return "^(" + strings.Join(alternatives, "|") + ")"
+}
+
+// canaryRoute is a route of a canary ingress, before it is restricted to the requests sent to the canary.
+type canaryRoute struct {
+	caddyhttp.Route
+	ingress *v1.Ingress
+	config  canaryConfig
+}
+
+// canaryError reports an invalid canary on its ingress.
+func canaryError(ing *v1.Ingress, host, path string, err error) error {
+	return &converter.IngressError{
+		Ingress: ing,
+		Host:    host,
+		Path:    path,
+		Plugin:  IngressPlugin{}.IngressPlugin().Name,
+		Err:     err,
+	}
+}
+
+// sameHostAndPath returns whether two routes match the same host and path.
+func sameHostAndPath(a, b caddyhttp.Route) bool {
+	if len(a.MatcherSetsRaw) == 0 || len(b.MatcherSetsRaw) == 0 {
+		return len(a.MatcherSetsRaw) == len(b.MatcherSetsRaw)
+	}
+	for _, name := range []string{"host", "path", "path_regexp"} {
+		if !bytes.Equal(a.MatcherSetsRaw[0][name], b.MatcherSetsRaw[0][name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// routeHostAndPath returns the first host and path matched by a route, for error messages.
+func routeHostAndPath(r caddyhttp.Route) (string, string) {
+	if len(r.MatcherSetsRaw) == 0 {
+		return "", ""
+	}
+	var hosts caddyhttp.MatchHost
+	_ = json.Unmarshal(r.MatcherSetsRaw[0]["host"], &hosts)
+
+	var host string
+	if len(hosts) > 0 {
+		host = hosts[0]
+	}
+	path, _ := routePath(r)
+	return host, path
+}
+
+// insertCanaries inserts the canary routes of a group right before the route of their primary
+// ingress with the same host and path, restricted to the requests sent to the canary.
+// Canary routes without primary route are reported and dropped.
+func insertCanaries(primary caddyhttp.RouteList, canaries []canaryRoute, group string) (caddyhttp.RouteList, []error) {
+	before := map[int]caddyhttp.RouteList{}
+	var errs []error
+	for _, c := range canaries {
+		if c.Group != group {
+			continue
+		}
+
+		idx := slices.IndexFunc(primary, func(p caddyhttp.Route) bool { return sameHostAndPath(p, c.Route) })
+		if idx < 0 {
+			host, path := routeHostAndPath(c.Route)
+			errs = append(errs, canaryError(c.ingress, host, path, fmt.Errorf("no primary Ingress for the host and path of this canary")))
+			continue
+		}
+
+		// a canary without traffic has no route
+		base := caddy.ModuleMap{}
+		if len(c.MatcherSetsRaw) > 0 {
+			base = c.MatcherSetsRaw[0]
+		}
+		sets := c.config.matcherSets(base)
+		if len(sets) == 0 {
+			continue
+		}
+
+		r := c.Route
+		r.MatcherSetsRaw = sets
+		before[idx] = append(before[idx], r)
+	}
+
+	routes := make(caddyhttp.RouteList, 0, len(primary)+len(before))
+	for i, r := range primary {
+		routes = append(routes, before[i]...)
+		routes = append(routes, r)
+	}
+	return routes, errs
+}
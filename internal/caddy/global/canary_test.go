@@ -0,0 +1,187 @@
+package global
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWeightPattern(t *testing.T) {
+	testCases := []struct {
+		weight   int
+		expected string
+	}{
+		{weight: 1, expected: "^(0[012])"},
+		{weight: 25, expected: "^([0123][0-9a-f])"},
+		{weight: 50, expected: "^([01234567][0-9a-f])"},
+		{weight: 30, expected: "^([0123][0-9a-f]|4[0123456789abc])"},
+		{weight: 99, expected: "^([0123456789abcde][0-9a-f]|f[0123456789abc])"},
+	}
+
+	for _, tC := range testCases {
+		pattern := weightPattern(tC.weight)
+		require.Equal(t, tC.expected, pattern, "weight %d", tC.weight)
+
+		// count the 2 digit prefixes going to the canary
+		re := regexp.MustCompile(pattern)
+		matching := 0
+		for i := 0; i < 256; i++ {
+			if re.MatchString(string([]byte{hexDigits[i/16], hexDigits[i%16]})) {
+				matching++
+			}
+		}
+		require.InDelta(t, float64(tC.weight)/100, float64(matching)/256, 0.002, "weight %d", tC.weight)
+	}
+}
+
+func TestParseCanary(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		annotations   map[string]string
+		expected      canaryConfig
+		expectedError string
+	}{
+		{
+			desc: "all annotations",
+			annotations: map[string]string{
+				canaryWeightAnnotation:        "20",
+				canaryByHeaderAnnotation:      "X-Canary",
+				canaryByHeaderValueAnnotation: "beta",
+				canaryByCookieAnnotation:      "canary",
+			},
+			expected: canaryConfig{weight: 20, header: "X-Canary", headerValue: "beta", cookie: "canary"},
+		},
+		{
+			desc:          "weight out of range",
+			annotations:   map[string]string{canaryWeightAnnotation: "120"},
+			expectedError: `invalid caddy.ingress.kubernetes.io/canary-weight "120", expected a percentage between 0 and 100`,
+		},
+		{
+			desc:          "header value without header",
+			annotations:   map[string]string{canaryByHeaderValueAnnotation: "beta"},
+			expectedError: "caddy.ingress.kubernetes.io/canary-by-header-value requires caddy.ingress.kubernetes.io/canary-by-header",
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			cfg, err := parseCanary(&v1.Ingress{ObjectMeta: metav1.ObjectMeta{Annotations: tC.annotations}})
+			if tC.expectedError != "" {
+				require.EqualError(t, err, tC.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tC.expected, cfg)
+		})
+	}
+}
+
+func TestCanaryMatcherSets(t *testing.T) {
+	base := caddy.ModuleMap{"path": caddyconfig.JSON(caddyhttp.MatchPath{"/app"}, nil)}
+
+	testCases := []struct {
+		desc     string
+		config   canaryConfig
+		headers  http.Header
+		path     string
+		expected bool
+	}{
+		{
+			desc:     "no traffic",
+			config:   canaryConfig{},
+			expected: false,
+		},
+		{
+			desc:     "full weight",
+			config:   canaryConfig{weight: 100},
+			expected: true,
+		},
+		{
+			desc:     "full weight on another path",
+			config:   canaryConfig{weight: 100},
+			path:     "/other",
+			expected: false,
+		},
+		{
+			desc:     "header always",
+			config:   canaryConfig{header: "X-Canary"},
+			headers:  http.Header{"X-Canary": {"always"}},
+			expected: true,
+		},
+		{
+			desc:     "header never takes precedence over weight",
+			config:   canaryConfig{weight: 100, header: "X-Canary"},
+			headers:  http.Header{"X-Canary": {"never"}},
+			expected: false,
+		},
+		{
+			desc:     "header value",
+			config:   canaryConfig{header: "X-Canary", headerValue: "beta"},
+			headers:  http.Header{"X-Canary": {"beta"}},
+			expected: true,
+		},
+		{
+			desc:     "other header value",
+			config:   canaryConfig{header: "X-Canary", headerValue: "beta"},
+			headers:  http.Header{"X-Canary": {"always"}},
+			expected: false,
+		},
+		{
+			desc:     "cookie always",
+			config:   canaryConfig{cookie: "canary"},
+			headers:  http.Header{"Cookie": {"session=abc; canary=always"}},
+			expected: true,
+		},
+		{
+			desc:     "header never takes precedence over cookie",
+			config:   canaryConfig{header: "X-Canary", cookie: "canary"},
+			headers:  http.Header{"X-Canary": {"never"}, "Cookie": {"canary=always"}},
+			expected: false,
+		},
+		{
+			desc:     "cookie never takes precedence over weight",
+			config:   canaryConfig{weight: 100, cookie: "canary"},
+			headers:  http.Header{"Cookie": {"canary=never"}},
+			expected: false,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			ctx, cancel := caddy.NewContext(caddy.Context{Context: context.Background()})
+			defer cancel()
+
+			route := caddyhttp.Route{MatcherSetsRaw: tC.config.matcherSets(base)}
+			if len(route.MatcherSetsRaw) == 0 {
+				require.False(t, tC.expected)
+				return
+			}
+			require.NoError(t, route.ProvisionMatchers(ctx))
+
+			path := tC.path
+			if path == "" {
+				path = "/app"
+			}
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			req.Header = tC.headers
+			if req.Header == nil {
+				req.Header = http.Header{}
+			}
+			repl := caddyhttp.NewTestReplacer(req)
+			req = req.WithContext(context.WithValue(req.Context(), caddy.ReplacerCtxKey, repl))
+
+			got, err := route.MatcherSets.AnyMatchWithError(req)
+			require.NoError(t, err)
+			require.Equal(t, tC.expected, got)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package global
+
+import (
+	"encoding/json"
+	"slices"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+	"github.com/caddyserver/ingress/internal/debug"
+	"github.com/caddyserver/ingress/pkg/converter"
+	"github.com/caddyserver/ingress/pkg/store"
+	v1 "k8s.io/api/networking/v1"
+)
+
+type DebugPlugin struct{}
+
+func (p DebugPlugin) IngressPlugin() converter.PluginInfo {
+	return converter.PluginInfo{
+		Name:     "debug",
+		Priority: -30,
+		New:      func() converter.Plugin { return new(DebugPlugin) },
+	}
+}
+
+func init() {
+	converter.RegisterPlugin(DebugPlugin{})
+}
+
+type debugPlugin struct {
+	Name     string `json:"name"`
+	Priority int    `json:"priority"`
+}
+
+// GlobalHandler in DebugPlugin serves the debug endpoints on the metrics server and
+// snapshots the store, plugins and per-Ingress routes of this conversion for them.
+// It runs last so that the routes of all Ingresses are generated.
+func (p DebugPlugin) GlobalHandler(config *converter.Config, store *store.Store) error {
+	if store.ConfigMap == nil || !store.ConfigMap.DebugEndpoints {
+		return nil
+	}
+
+	debugRoute := caddyhttp.Route{
+		HandlersRaw: []json.RawMessage{json.RawMessage(`{ "handler": "ingress_debug" }`)},
+		MatcherSetsRaw: []caddy.ModuleMap{{
+			"path": caddyconfig.JSON(caddyhttp.MatchPath{debug.PathPrefix + "/*"}, nil),
+		}},
+	}
+	config.GetMetricsServer().Routes = append(config.GetMetricsServer().Routes, debugRoute)
+
+	// redact secrets from the store
+	s := *store
+	cm := *store.ConfigMap
+	cm.AcmeEABMacKey = debug.Redact(cm.AcmeEABMacKey)
+	s.ConfigMap = &cm
+	s.Ingresses = redactIngresses(store.Ingresses)
+
+	var plugins []debugPlugin
+	for _, plugin := range converter.Plugins(store.Options.PluginsOrder) {
+		info := plugin.IngressPlugin()
+		plugins = append(plugins, debugPlugin{Name: info.Name, Priority: info.Priority})
+	}
+
+	return debug.SetConversion(s, plugins, ingressRoutesSnapshot(config, store))
+}
+
+// sensitiveAnnotations are the annotations of Ingresses holding secrets. The last applied
+// configuration of kubectl has a copy of all annotations.
+var sensitiveAnnotations = []string{
+	"caddy.ingress.kubernetes.io/lb-policy-cookie-secret",
+	"caddy.ingress.kubernetes.io/health-check-headers",
+	"kubectl.kubernetes.io/last-applied-configuration",
+}
+
+// redactIngresses returns the ingresses with copies of the ones with sensitive annotations
+// where they are redacted.
+func redactIngresses(ingresses []*v1.Ingress) []*v1.Ingress {
+	redacted := make([]*v1.Ingress, 0, len(ingresses))
+	for _, ing := range ingresses {
+		if slices.ContainsFunc(sensitiveAnnotations, func(name string) bool { return ing.Annotations[name] != "" }) {
+			ing = ing.DeepCopy()
+			for _, name := range sensitiveAnnotations {
+				if val, ok := ing.Annotations[name]; ok {
+					ing.Annotations[name] = debug.Redact(val)
+				}
+			}
+		}
+		redacted = append(redacted, ing)
+	}
+	return redacted
+}
+
+// ingressRoutesSnapshot returns the routes served for each Ingress, by namespace/name.
+func ingressRoutesSnapshot(config *converter.Config, store *store.Store) map[string]caddyhttp.RouteList {
+	routes := map[string]caddyhttp.RouteList{}
+	for _, ing := range store.Ingresses {
+		if r, ok := config.IngressRoutes[ing.UID]; ok {
+			routes[ing.Namespace+"/"+ing.Name] = r
+		}
+	}
+	return routes
+}
+
+// Interface guards
+var (
+	_ = converter.GlobalMiddleware(DebugPlugin{})
+)
@@ -0,0 +1,33 @@
+package global
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRedactIngresses(t *testing.T) {
+	sensitive := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		"caddy.ingress.kubernetes.io/health-check-path":       "/healthz",
+		"caddy.ingress.kubernetes.io/health-check-headers":    "Authorization: Bearer t0k3n",
+		"caddy.ingress.kubernetes.io/lb-policy-cookie-secret": "s3cr3t",
+		"kubectl.kubernetes.io/last-applied-configuration":    `{"metadata":{}}`,
+	}}}
+	plain := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		"caddy.ingress.kubernetes.io/health-check-path": "/healthz",
+	}}}
+
+	redacted := redactIngresses([]*networkingv1.Ingress{sensitive, plain})
+
+	require.Equal(t, map[string]string{
+		"caddy.ingress.kubernetes.io/health-check-path":       "/healthz",
+		"caddy.ingress.kubernetes.io/health-check-headers":    "[REDACTED]",
+		"caddy.ingress.kubernetes.io/lb-policy-cookie-secret": "[REDACTED]",
+		"kubectl.kubernetes.io/last-applied-configuration":    "[REDACTED]",
+	}, redacted[0].Annotations)
+	require.Equal(t, "Authorization: Bearer t0k3n", sensitive.Annotations["caddy.ingress.kubernetes.io/health-check-headers"],
+		"the ingress from the store must not be modified")
+	require.Same(t, plain, redacted[1])
+}
@@ -2,12 +2,12 @@ package global
 
 import (
 	"encoding/json"
-	"net/http"
-	"strconv"
+	"slices"
 
 	"github.com/caddyserver/caddy/v2"
 	"github.com/caddyserver/caddy/v2/caddyconfig"
 	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+	"github.com/caddyserver/ingress/internal/health"
 	"github.com/caddyserver/ingress/pkg/converter"
 	"github.com/caddyserver/ingress/pkg/store"
 )
@@ -26,20 +26,49 @@ func init() {
 	converter.RegisterPlugin(HealthzPlugin{})
 }
 
+// GlobalHandler in HealthzPlugin serves the liveness probe on /healthz and the readiness probe on /readyz.
 func (p HealthzPlugin) GlobalHandler(config *converter.Config, store *store.Store) error {
-	healthzHandler := caddyhttp.StaticResponse{StatusCode: caddyhttp.WeakString(strconv.Itoa(http.StatusOK))}
+	liveness := health.Handler{
+		Check:        health.Liveness,
+		StuckTimeout: caddy.Duration(store.Options.StuckWorkerTimeout),
+	}
+	readiness := health.Handler{
+		Check: health.Readiness,
+	}
 
-	healthzRoute := caddyhttp.Route{
+	// only certificates of TLS secrets are checked, a host whose certificate cannot be
+	// obtained with ACME would make all the replicas unready
+	if store.ConfigMap != nil && store.ConfigMap.ReadinessCheckCertificates {
+		for _, ing := range store.Ingresses {
+			for _, tlsRule := range ing.Spec.TLS {
+				if tlsRule.SecretName == "" {
+					continue
+				}
+				for _, h := range tlsRule.Hosts {
+					if !slices.Contains(readiness.Subjects, h) {
+						readiness.Subjects = append(readiness.Subjects, h)
+					}
+				}
+			}
+		}
+	}
+
+	config.GetMetricsServer().Routes = append(config.GetMetricsServer().Routes,
+		healthRoute("/healthz", liveness),
+		healthRoute("/readyz", readiness),
+	)
+	return nil
+}
+
+func healthRoute(path string, handler health.Handler) caddyhttp.Route {
+	return caddyhttp.Route{
 		HandlersRaw: []json.RawMessage{
-			caddyconfig.JSONModuleObject(healthzHandler, "handler", healthzHandler.CaddyModule().ID.Name(), nil),
+			caddyconfig.JSONModuleObject(handler, "handler", handler.CaddyModule().ID.Name(), nil),
 		},
 		MatcherSetsRaw: []caddy.ModuleMap{{
-			"path": caddyconfig.JSON(caddyhttp.MatchPath{"/healthz"}, nil),
+			"path": caddyconfig.JSON(caddyhttp.MatchPath{path}, nil),
 		}},
 	}
-
-	config.GetMetricsServer().Routes = append(config.GetMetricsServer().Routes, healthzRoute)
-	return nil
 }
 
 // Interface guards
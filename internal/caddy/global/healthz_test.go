@@ -0,0 +1,38 @@
+package global
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/caddyserver/ingress/internal/health"
+	"github.com/caddyserver/ingress/pkg/converter"
+	"github.com/caddyserver/ingress/pkg/store"
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+func TestReadinessCheckCertificates(t *testing.T) {
+	s := store.NewStore(store.Options{}, "", &store.PodInfo{})
+	s.ConfigMap = &store.ConfigMapOptions{ReadinessCheckCertificates: true}
+	s.AddIngress(&networkingv1.Ingress{
+		Spec: networkingv1.IngressSpec{
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"secret.example.com", "*.example.com"}, SecretName: "tls"},
+				{Hosts: []string{"no-secret.example.com"}},
+			},
+			Rules: []networkingv1.IngressRule{
+				{Host: "secret.example.com"},
+				{Host: "acme.example.com"},
+			},
+		},
+	})
+
+	config := converter.NewConfig()
+	require.NoError(t, HealthzPlugin{}.GlobalHandler(config, s))
+
+	var readiness health.Handler
+	route := config.GetMetricsServer().Routes[1]
+	require.NoError(t, json.Unmarshal(route.HandlersRaw[0], &readiness))
+	require.Equal(t, health.Readiness, readiness.Check)
+	require.Equal(t, []string{"secret.example.com", "*.example.com"}, readiness.Subjects)
+}
@@ -2,13 +2,23 @@ package global
 
 import (
 	"encoding/json"
+	"errors"
+	"slices"
+	"sort"
+	"strings"
 
 	"github.com/caddyserver/caddy/v2"
 	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
 	"github.com/caddyserver/ingress/pkg/converter"
 	"github.com/caddyserver/ingress/pkg/store"
+	v1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
+// defaultBackendGroup is the group of the routes of default backends. They are kept after the
+// routes of ingress rules, and only one of them handles a request.
+const defaultBackendGroup = "default_backend"
+
 type IngressPlugin struct{}
 
 func (p IngressPlugin) IngressPlugin() converter.PluginInfo {
@@ -22,46 +32,207 @@ func init() {
 	converter.RegisterPlugin(IngressPlugin{})
 }
 
+type namedIngressMiddleware struct {
+	name string
+	converter.IngressMiddleware
+}
+
+// GlobalHandler in IngressPlugin generates a route for each path of each ingress.
+//
+// Routes of exact hosts go first, then the ones of wildcard hosts and finally the ones
+// matching any host, so that the most specific host handles a request.
+// Routes of canary ingresses go right before the route of their primary ingress for the
+// same host and path, and only match the requests sent to the canary.
+//
+// Errors are isolated per Ingress: an Ingress that fails to convert is served with the
+// routes of its last successful conversion found in config.IngressRoutes (or skipped if there
+// is none) and the conversion of other Ingresses goes on. Such failures are returned as
+// converter.IngressError.
 func (p IngressPlugin) GlobalHandler(config *converter.Config, store *store.Store) error {
-	ingressHandlers := make([]converter.IngressMiddleware, 0)
+	ingressHandlers := make([]namedIngressMiddleware, 0)
 	for _, plugin := range converter.Plugins(store.Options.PluginsOrder) {
 		if m, ok := plugin.(converter.IngressMiddleware); ok {
-			ingressHandlers = append(ingressHandlers, m)
+			ingressHandlers = append(ingressHandlers, namedIngressMiddleware{
+				name:              plugin.IngressPlugin().Name,
+				IngressMiddleware: m,
+			})
 		}
 	}
 
+	// the controller-wide default backend is handled like the default backend of an ingress
+	ingresses := store.Ingresses
+	if def := store.DefaultBackend(); def != nil {
+		ingresses = append(slices.Clone(ingresses), def)
+	}
+
 	// create a server route for each ingress route
+	var routes, defaultRoutes caddyhttp.RouteList
+	var canaries []canaryRoute
+	var errs []error
+	seen := map[types.UID]bool{}
+	for _, ing := range ingresses {
+		seen[ing.UID] = true
+
+		ingRoutes, err := ingressRoutes(config, store, ing, ingressHandlers)
+		if err != nil {
+			errs = append(errs, err)
+			ingRoutes = config.IngressRoutes[ing.UID]
+		} else {
+			config.IngressRoutes[ing.UID] = ingRoutes
+		}
+
+		// a misconfigured canary gets no traffic
+		if isCanary(ing) {
+			cfg, err := parseCanary(ing)
+			if err != nil {
+				errs = append(errs, canaryError(ing, "", "", err))
+				continue
+			}
+			for _, r := range ingRoutes {
+				canaries = append(canaries, canaryRoute{Route: r, ingress: ing, config: cfg})
+			}
+			continue
+		}
+
+		for _, r := range ingRoutes {
+			if r.Group == defaultBackendGroup {
+				defaultRoutes = append(defaultRoutes, r)
+			} else {
+				routes = append(routes, r)
+			}
+		}
+	}
+
+	// canaries get the requests they match before their primary ingress
+	routes, canaryErrs := insertCanaries(routes, canaries, "")
+	errs = append(errs, canaryErrs...)
+	defaultRoutes, canaryErrs = insertCanaries(defaultRoutes, canaries, defaultBackendGroup)
+	errs = append(errs, canaryErrs...)
+
+	// forget about deleted ingresses and a removed default backend
+	for uid := range config.IngressRoutes {
+		if !seen[uid] {
+			delete(config.IngressRoutes, uid)
+		}
+	}
+
+	// exact hosts take precedence over wildcard hosts, which take precedence over any host,
+	// whatever the order of ingresses
+	sortByHost(routes)
+	sortByHost(defaultRoutes)
+
+	config.GetHTTPServer().Routes = append(routes, defaultRoutes...)
+	return errors.Join(errs...)
+}
+
+// ingressRoutes generates the routes of a single ingress, including the routes of its default backend.
+func ingressRoutes(config *converter.Config, store *store.Store, ing *v1.Ingress, ingressHandlers []namedIngressMiddleware) (caddyhttp.RouteList, error) {
 	var routes caddyhttp.RouteList
-	for _, ing := range store.Ingresses {
-		for _, rule := range ing.Spec.Rules {
-			for _, path := range rule.HTTP.Paths {
-				r := &caddyhttp.Route{
-					HandlersRaw:    []json.RawMessage{},
-					MatcherSetsRaw: []caddy.ModuleMap{},
-				}
-
-				for _, middleware := range ingressHandlers {
-					newRoute, err := middleware.IngressHandler(converter.IngressMiddlewareInput{
-						Config:  config,
-						Store:   store,
-						Ingress: ing,
-						Rule:    rule,
-						Path:    path,
-						Route:   r,
-					})
-					if err != nil {
-						return err
-					}
-					r = newRoute
-				}
-
-				routes = append(routes, *r)
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+
+		for _, path := range rule.HTTP.Paths {
+			r, err := ingressRoute(config, store, ing, rule, path, ingressHandlers)
+			if err != nil {
+				return nil, err
 			}
+			routes = append(routes, *r)
 		}
 	}
 
-	config.GetHTTPServer().Routes = routes
-	return nil
+	// the default backend serves the requests to the hosts of the ingress that match no path
+	if ing.Spec.DefaultBackend != nil {
+		for _, host := range defaultBackendHosts(ing) {
+			rule := v1.IngressRule{Host: host}
+			path := v1.HTTPIngressPath{Backend: *ing.Spec.DefaultBackend}
+			r, err := ingressRoute(config, store, ing, rule, path, ingressHandlers)
+			if err != nil {
+				return nil, err
+			}
+			r.Group = defaultBackendGroup
+			routes = append(routes, *r)
+		}
+	}
+	return routes, nil
+}
+
+// ingressRoute generates the route of a single path of an ingress.
+func ingressRoute(config *converter.Config, store *store.Store, ing *v1.Ingress, rule v1.IngressRule, path v1.HTTPIngressPath, ingressHandlers []namedIngressMiddleware) (*caddyhttp.Route, error) {
+	r := &caddyhttp.Route{
+		HandlersRaw:    []json.RawMessage{},
+		MatcherSetsRaw: []caddy.ModuleMap{},
+	}
+
+	for _, middleware := range ingressHandlers {
+		newRoute, err := middleware.IngressHandler(converter.IngressMiddlewareInput{
+			Config:  config,
+			Store:   store,
+			Ingress: ing,
+			Rule:    rule,
+			Path:    path,
+			Route:   r,
+		})
+		if err != nil {
+			return nil, &converter.IngressError{
+				Ingress: ing,
+				Host:    rule.Host,
+				Path:    path.Path,
+				Plugin:  middleware.name,
+				Err:     err,
+			}
+		}
+		r = newRoute
+	}
+	return r, nil
+}
+
+// defaultBackendHosts returns the hosts served by the default backend of an ingress,
+// an empty host meaning any host.
+func defaultBackendHosts(ing *v1.Ingress) []string {
+	var hosts []string
+	for _, rule := range ing.Spec.Rules {
+		if rule.Host == "" {
+			return []string{""}
+		}
+		if !slices.Contains(hosts, rule.Host) {
+			hosts = append(hosts, rule.Host)
+		}
+	}
+	if len(hosts) == 0 {
+		return []string{""}
+	}
+	return hosts
+}
+
+// Kinds of route hosts, in the order of their precedence.
+const (
+	exactHost = iota
+	wildcardHost
+	anyHost
+)
+
+// routeHostKind returns the kind of the host matched by a route.
+func routeHostKind(r caddyhttp.Route) int {
+	for _, set := range r.MatcherSetsRaw {
+		var hosts caddyhttp.MatchHost
+		if raw, ok := set["host"]; ok && json.Unmarshal(raw, &hosts) == nil && len(hosts) > 0 {
+			if strings.Contains(hosts[0], "*") {
+				return wildcardHost
+			}
+			return exactHost
+		}
+	}
+	return anyHost
+}
+
+// sortByHost sorts routes by the precedence of their host, keeping the order of routes
+// of the same kind.
+func sortByHost(routes caddyhttp.RouteList) {
+	sort.SliceStable(routes, func(i, j int) bool {
+		return routeHostKind(routes[i]) < routeHostKind(routes[j])
+	})
 }
 
 // Interface guards
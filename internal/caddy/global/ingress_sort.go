@@ -34,23 +34,56 @@ func getFirstItemFromJSON(data json.RawMessage) string {
 	return arr[0]
 }
 
+// Kinds of route paths, in the order they are sorted.
+const (
+	exactPath = iota
+	regexpPath
+	prefixPath
+	emptyPath
+)
+
+// routePath returns the first path of a route matcher and its kind.
+func routePath(route caddyhttp.Route) (string, int) {
+	if raw, ok := route.MatcherSetsRaw[0]["path_regexp"]; ok {
+		var re caddyhttp.MatchRegexp
+		if err := json.Unmarshal(raw, &re); err == nil {
+			return re.Pattern, regexpPath
+		}
+	}
+
+	path := getFirstItemFromJSON(route.MatcherSetsRaw[0]["path"])
+	switch {
+	case path == "":
+		return path, emptyPath
+	case strings.HasSuffix(path, "*"):
+		return path, prefixPath
+	}
+	return path, exactPath
+}
+
 func sortRoutes(routes caddyhttp.RouteList) {
 	sort.SliceStable(routes, func(i, j int) bool {
-		iPath := getFirstItemFromJSON(routes[i].MatcherSetsRaw[0]["path"])
-		jPath := getFirstItemFromJSON(routes[j].MatcherSetsRaw[0]["path"])
-		iPrefixed := strings.HasSuffix(iPath, "*")
-		jPrefixed := strings.HasSuffix(jPath, "*")
+		// Default backends always go last
+		iDefault := routes[i].Group == defaultBackendGroup
+		jDefault := routes[j].Group == defaultBackendGroup
+		if iDefault || jDefault {
+			return !iDefault
+		}
+
+		// Routes keep the precedence of their host
+		iHost, jHost := routeHostKind(routes[i]), routeHostKind(routes[j])
+		if iHost != jHost {
+			return iHost < jHost
+		}
+
+		iPath, iKind := routePath(routes[i])
+		jPath, jKind := routePath(routes[j])
 
 		// If both same type check by length
-		if iPrefixed == jPrefixed {
+		if iKind == jKind {
 			return len(jPath) < len(iPath)
 		}
-		// Empty path will be moved last
-		if jPath == "" || iPath == "" {
-			return jPath == ""
-		}
-		// j path is exact so should go first
-		return jPrefixed
+		return iKind < jKind
 	})
 }
 
@@ -58,8 +91,10 @@ func sortRoutes(routes caddyhttp.RouteList) {
 //
 // It only supports basic conflicts for now. It doesn't support multiple matchers in the same route
 // nor multiple path/host in the matcher. It shouldn't be an issue with the ingress.matcher plugin.
-// Sort will prioritize exact paths then prefix paths and finally empty paths.
-// When 2 exacts paths or 2 prefixed paths are on the same host, we choose the longer first.
+// Routes of exact hosts stay before the ones of wildcard hosts and of any host.
+// Sort will prioritize exact paths then regular expressions, prefix paths and finally empty paths.
+// Routes of default backends are kept last, in the order set by the ingress plugin.
+// When 2 paths of the same kind are on the same host, we choose the longer first.
 func (p IngressSortPlugin) GlobalHandler(config *converter.Config, store *store.Store) error {
 	if !store.ConfigMap.ExperimentalSmartSort {
 		return nil
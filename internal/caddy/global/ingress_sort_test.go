@@ -14,8 +14,9 @@ func TestIngressSort(t *testing.T) {
 	tests := []struct {
 		name   string
 		routes []struct {
-			id   int
-			path string
+			id     int
+			path   string
+			regexp bool
 		}
 		expect []int
 	}{
@@ -23,8 +24,9 @@ func TestIngressSort(t *testing.T) {
 		{
 			name: "multiple exact paths",
 			routes: []struct {
-				id   int
-				path string
+				id     int
+				path   string
+				regexp bool
 			}{
 				{id: 0, path: "/path/a"},
 				{id: 1, path: "/path/"},
@@ -35,8 +37,9 @@ func TestIngressSort(t *testing.T) {
 		{
 			name: "multiple prefix paths",
 			routes: []struct {
-				id   int
-				path string
+				id     int
+				path   string
+				regexp bool
 			}{
 				{id: 0, path: "/path/*"},
 				{id: 1, path: "/path/auth/*"},
@@ -48,8 +51,9 @@ func TestIngressSort(t *testing.T) {
 		{
 			name: "mixed exact and prefixed",
 			routes: []struct {
-				id   int
-				path string
+				id     int
+				path   string
+				regexp bool
 			}{
 				{id: 0, path: "/path/*"},
 				{id: 1, path: "/path/auth/"},
@@ -61,8 +65,9 @@ func TestIngressSort(t *testing.T) {
 		{
 			name: "mixed exact, prefix and empty",
 			routes: []struct {
-				id   int
-				path string
+				id     int
+				path   string
+				regexp bool
 			}{
 				{id: 0, path: "/path/*"},
 				{id: 1, path: ""},
@@ -72,6 +77,21 @@ func TestIngressSort(t *testing.T) {
 			},
 			expect: []int{3, 2, 0, 1, 4},
 		},
+		{
+			name: "regular expressions between exact and prefix paths",
+			routes: []struct {
+				id     int
+				path   string
+				regexp bool
+			}{
+				{id: 0, path: "/path/*"},
+				{id: 1, path: "^/path/[0-9]+", regexp: true},
+				{id: 2, path: ""},
+				{id: 3, path: "/path/new"},
+				{id: 4, path: "^/path/(v1|v2)/.*", regexp: true},
+			},
+			expect: []int{3, 4, 1, 0, 2},
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -81,7 +101,9 @@ func TestIngressSort(t *testing.T) {
 				match := caddy.ModuleMap{}
 				match["id"] = caddyconfig.JSON(route.id, nil)
 
-				if route.path != "" {
+				if route.regexp {
+					match["path_regexp"] = caddyconfig.JSON(caddyhttp.MatchPathRE{MatchRegexp: caddyhttp.MatchRegexp{Pattern: route.path}}, nil)
+				} else if route.path != "" {
 					match["path"] = caddyconfig.JSON(caddyhttp.MatchPath{route.path}, nil)
 				}
 
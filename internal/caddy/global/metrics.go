@@ -6,6 +6,7 @@ import (
 	"github.com/caddyserver/caddy/v2"
 	"github.com/caddyserver/caddy/v2/caddyconfig"
 	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+	_ "github.com/caddyserver/ingress/internal/metrics"
 	"github.com/caddyserver/ingress/pkg/converter"
 	"github.com/caddyserver/ingress/pkg/store"
 )
@@ -25,8 +26,12 @@ func init() {
 
 func (p MetricsPlugin) GlobalHandler(config *converter.Config, store *store.Store) error {
 	if store.ConfigMap.Metrics {
+		// controller metrics are registered before caddy metrics handler serves them
 		metricsRoute := caddyhttp.Route{
-			HandlersRaw: []json.RawMessage{json.RawMessage(`{ "handler": "metrics" }`)},
+			HandlersRaw: []json.RawMessage{
+				json.RawMessage(`{ "handler": "controller_metrics" }`),
+				json.RawMessage(`{ "handler": "metrics" }`),
+			},
 			MatcherSetsRaw: []caddy.ModuleMap{{
 				"path": caddyconfig.JSON(caddyhttp.MatchPath{"/metrics"}, nil),
 			}},
@@ -3,6 +3,7 @@ package global
 import (
 	"encoding/json"
 	"slices"
+	"strings"
 
 	"github.com/caddyserver/ingress/internal/controller"
 	"github.com/caddyserver/ingress/pkg/converter"
@@ -22,13 +23,44 @@ func init() {
 	converter.RegisterPlugin(TLSPlugin{})
 }
 
+// GlobalHandler in TLSPlugin loads the certificates of TLS secrets and skips the automatic
+// management of the hosts they cover.
+//
+// Wildcard hosts of rules are skipped as well: their certificate cannot be obtained with the
+// HTTP or TLS-ALPN challenges, they are served by a TLS secret or with on-demand TLS, which
+// obtains a certificate for each name during the handshake.
 func (p TLSPlugin) GlobalHandler(config *converter.Config, store *store.Store) error {
 	tlsApp := config.GetTLSApp()
 	httpServer := config.GetHTTPServer()
 
-	var hosts []string
+	hosts := tlsHosts(store)
 
-	// Get all Hosts subject to custom TLS certs
+	skipCerts := slices.Clone(hosts)
+	for _, ing := range store.Ingresses {
+		for _, rule := range ing.Spec.Rules {
+			h := rule.Host
+			if h == "" || slices.Contains(skipCerts, h) {
+				continue
+			}
+			if isWildcardHost(h) || slices.ContainsFunc(hosts, func(pattern string) bool { return matchesWildcardHost(h, pattern) }) {
+				skipCerts = append(skipCerts, h)
+			}
+		}
+	}
+
+	if len(hosts) > 0 {
+		tlsApp.CertificatesRaw["load_folders"] = json.RawMessage(`["` + controller.GetCertFolder() + `"]`)
+	}
+	if len(skipCerts) > 0 {
+		// do not manage certificates for those hosts
+		httpServer.AutoHTTPS.SkipCerts = skipCerts
+	}
+	return nil
+}
+
+// tlsHosts returns all hosts subject to custom TLS certs.
+func tlsHosts(store *store.Store) []string {
+	var hosts []string
 	for _, ing := range store.Ingresses {
 		for _, tlsRule := range ing.Spec.TLS {
 			for _, h := range tlsRule.Hosts {
@@ -38,13 +70,22 @@ func (p TLSPlugin) GlobalHandler(config *converter.Config, store *store.Store) e
 			}
 		}
 	}
+	return hosts
+}
 
-	if len(hosts) > 0 {
-		tlsApp.CertificatesRaw["load_folders"] = json.RawMessage(`["` + controller.GetCertFolder() + `"]`)
-		// do not manage certificates for those hosts
-		httpServer.AutoHTTPS.SkipCerts = hosts
+// isWildcardHost returns whether the host is a wildcard host, like *.example.com.
+func isWildcardHost(host string) bool {
+	return strings.HasPrefix(host, "*.")
+}
+
+// matchesWildcardHost returns whether a host matches a wildcard host pattern.
+// As for Ingress rules, the wildcard matches exactly one DNS label.
+func matchesWildcardHost(host, pattern string) bool {
+	if !isWildcardHost(pattern) || isWildcardHost(host) {
+		return false
 	}
-	return nil
+	label, ok := strings.CutSuffix(host, pattern[1:])
+	return ok && label != "" && !strings.Contains(label, ".")
 }
 
 // Interface guards
@@ -160,6 +160,44 @@ func TestIngressTlsSkipCertificates(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc:                "Wildcard host without certificate",
+			skippedCertsDomains: []string{"*.example.com"},
+			ingresses: []*networkingv1.Ingress{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						UID: types.UID("first"),
+					},
+					Spec: networkingv1.IngressSpec{
+						Rules: []networkingv1.IngressRule{{Host: "*.example.com"}, {Host: "example.com"}},
+					},
+				},
+			},
+		},
+		{
+			desc:                "Hosts covered by a wildcard certificate",
+			skippedCertsDomains: []string{"*.example.com", "a.example.com"},
+			ingresses: []*networkingv1.Ingress{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						UID: types.UID("first"),
+					},
+					Spec: networkingv1.IngressSpec{
+						TLS: []networkingv1.IngressTLS{{
+							Hosts: []string{"*.example.com"},
+						}},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						UID: types.UID("second"),
+					},
+					Spec: networkingv1.IngressSpec{
+						Rules: []networkingv1.IngressRule{{Host: "a.example.com"}, {Host: "a.b.example.com"}, {Host: "example.com"}},
+					},
+				},
+			},
+		},
 		{
 			desc:                "One ingress registered without certificate",
 			skippedCertsDomains: []string{},
@@ -13,6 +13,42 @@ const (
 	permanentRedirectCodeAnnotation = "permanent-redirect-code"
 	temporaryRedirectAnnotation     = "temporal-redirect"
 	trustedProxies                  = "trusted-proxies"
+	resourceIndex                   = "resource-index"
+	resourceSPAFallback             = "resource-spa-fallback"
+	externalNameHostHeader          = "external-name-host-header"
+	externalNameSNI                 = "external-name-sni"
+	useRegex                        = "use-regex"
+	lbPolicy                        = "lb-policy"
+	lbPolicyHeader                  = "lb-policy-header"
+	lbPolicyCookieName              = "lb-policy-cookie-name"
+	lbPolicyCookieSecret            = "lb-policy-cookie-secret"
+	lbPolicyCookieTTL               = "lb-policy-cookie-ttl"
+	lbTryDuration                   = "lb-try-duration"
+	lbTryInterval                   = "lb-try-interval"
+	lbRetries                       = "lb-retries"
+	lbRetryMethods                  = "lb-retry-methods"
+	lbRetryOn                       = "lb-retry-on"
+	healthCheckPath                 = "health-check-path"
+	healthCheckPort                 = "health-check-port"
+	healthCheckInterval             = "health-check-interval"
+	healthCheckTimeout              = "health-check-timeout"
+	healthCheckStatus               = "health-check-status"
+	healthCheckBody                 = "health-check-body"
+	healthCheckHeaders              = "health-check-headers"
+	healthCheckFailDuration         = "health-check-fail-duration"
+	healthCheckMaxFails             = "health-check-max-fails"
+	healthCheckUnhealthyStatus      = "health-check-unhealthy-status"
+	healthCheckUnhealthyLatency     = "health-check-unhealthy-latency"
+	proxyDialTimeout                = "proxy-dial-timeout"
+	proxyResponseHeaderTimeout      = "proxy-response-header-timeout"
+	proxyReadTimeout                = "proxy-read-timeout"
+	proxyWriteTimeout               = "proxy-write-timeout"
+	proxyKeepAliveIdleTimeout       = "proxy-keepalive-idle-timeout"
+	proxyKeepAliveMaxIdleConns      = "proxy-keepalive-max-idle-conns"
+	proxyRequestBuffers             = "proxy-request-buffers"
+	proxyResponseBuffers            = "proxy-response-buffers"
+	proxyMaxBodySize                = "proxy-max-body-size"
+	proxyFlushInterval              = "proxy-flush-interval"
 )
 
 func getAnnotation(ing *v1.Ingress, rule string) string {
@@ -0,0 +1,211 @@
+package ingress
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp/reverseproxy"
+	"github.com/caddyserver/ingress/pkg/store"
+	apiv1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/utils/ptr"
+)
+
+// serviceUpstreams returns the upstreams of the service referenced by a backend of the ingress,
+// depending on its upstream addressing mode.
+func serviceUpstreams(s *store.Store, ing *networkingv1.Ingress, backend *networkingv1.IngressServiceBackend, port apiv1.ServicePort) (reverseproxy.UpstreamPool, error) {
+	svc := s.GetService(ing.Namespace, backend.Name)
+
+	// ExternalName services are dialed directly instead of relying on the
+	// CNAME record of cluster DNS
+	if svc != nil && svc.Spec.Type == apiv1.ServiceTypeExternalName {
+		return reverseproxy.UpstreamPool{
+			{Dial: net.JoinHostPort(svc.Spec.ExternalName, strconv.Itoa(int(port.Port)))},
+		}, nil
+	}
+
+	family := upstreamIPFamily(svc, s.UpstreamIPFamily())
+
+	switch s.UpstreamAddressing(ing) {
+	case store.UpstreamAddressingEndpoints:
+		// bypass the service to proxy to each pod, so that load balancing,
+		// health checks and session affinity work per pod. Pods have no target
+		// port for a port the service does not list, it is dialed through the service.
+		if svc == nil || hasServicePort(svc, port) {
+			endpointSlices := s.ServiceEndpointSlices(ing.Namespace, backend.Name)
+			return podUpstreams(endpointSlices, port.Name, family), nil
+		}
+
+	case store.UpstreamAddressingClusterIP:
+		// headless services have no cluster IP, their DNS name resolves to their pods, and
+		// services that are not synced yet are dialed by name until they are
+		if svc != nil && svc.Spec.ClusterIP != "" && svc.Spec.ClusterIP != apiv1.ClusterIPNone {
+			ip, err := serviceClusterIP(svc, family)
+			if err != nil {
+				return nil, err
+			}
+			return reverseproxy.UpstreamPool{
+				{Dial: net.JoinHostPort(ip, strconv.Itoa(int(port.Port)))},
+			}, nil
+		}
+	}
+
+	return reverseproxy.UpstreamPool{
+		{Dial: fmt.Sprintf("%v.%v.svc.%v:%d", backend.Name, ing.Namespace, s.ClusterDomain(), port.Port)},
+	}, nil
+}
+
+// upstreamIPFamily returns the IP family of the upstreams of a service: family, or the primary
+// family of the service if family is empty.
+func upstreamIPFamily(svc *apiv1.Service, family apiv1.IPFamily) apiv1.IPFamily {
+	if family == "" && svc != nil && len(svc.Spec.IPFamilies) > 0 {
+		return svc.Spec.IPFamilies[0]
+	}
+	return family
+}
+
+// serviceClusterIP returns the cluster IP of a service of the IP family, or its primary
+// cluster IP if family is empty.
+func serviceClusterIP(svc *apiv1.Service, family apiv1.IPFamily) (string, error) {
+	if family == "" {
+		return svc.Spec.ClusterIP, nil
+	}
+
+	// cluster IPs are in the order of IP families
+	for i, f := range svc.Spec.IPFamilies {
+		if f == family && i < len(svc.Spec.ClusterIPs) {
+			return svc.Spec.ClusterIPs[i], nil
+		}
+	}
+	return "", fmt.Errorf("service %s has no %s cluster IP", svc.Name, family)
+}
+
+// podUpstreams returns an upstream for each ready endpoint of a service, using the target port
+// of its port named portName. Only endpoints of one IP family are used, as dual-stack services
+// have a slice of each family with the same pods: family, or the family of the first slice if
+// it is empty. Terminating endpoints are drained: they do not get new requests, unless no
+// endpoint is ready.
+func podUpstreams(endpointSlices []*discoveryv1.EndpointSlice, portName string, family apiv1.IPFamily) reverseproxy.UpstreamPool {
+	var ready, terminating []string
+	for _, slice := range endpointSlices {
+		if slice.AddressType != discoveryv1.AddressTypeIPv4 && slice.AddressType != discoveryv1.AddressTypeIPv6 {
+			continue
+		}
+		if family == "" {
+			family = apiv1.IPFamily(slice.AddressType)
+		}
+		if string(slice.AddressType) != string(family) {
+			continue
+		}
+
+		port, ok := endpointSlicePort(slice, portName)
+		if !ok {
+			continue
+		}
+
+		for _, ep := range slice.Endpoints {
+			if len(ep.Addresses) == 0 {
+				continue
+			}
+
+			// all addresses of an endpoint are the same pod, the first one is used
+			addr := net.JoinHostPort(ep.Addresses[0], strconv.Itoa(int(port)))
+			if isEndpointReady(ep) {
+				ready = append(ready, addr)
+			} else if isEndpointTerminating(ep) && isEndpointServing(ep) {
+				terminating = append(terminating, addr)
+			}
+		}
+	}
+
+	addrs := ready
+	if len(addrs) == 0 {
+		addrs = terminating
+	}
+
+	// keep upstreams in a stable order to avoid needless reloads
+	slices.Sort(addrs)
+	addrs = slices.Compact(addrs)
+
+	upstreams := reverseproxy.UpstreamPool{}
+	for _, addr := range addrs {
+		upstreams = append(upstreams, &reverseproxy.Upstream{Dial: addr})
+	}
+	return upstreams
+}
+
+// endpointSlicePort returns the port of the endpoints of the slice for a service port.
+// Endpoint ports are named after service ports, and the port of a single port service may be unnamed.
+func endpointSlicePort(slice *discoveryv1.EndpointSlice, portName string) (int32, bool) {
+	for _, p := range slice.Ports {
+		if p.Port != nil && ptr.Deref(p.Name, "") == portName {
+			return *p.Port, true
+		}
+	}
+	return 0, false
+}
+
+// servicePort returns the port of the service referenced by the backend.
+// The service is only required to resolve a port name.
+func servicePort(s *store.Store, namespace string, backend *networkingv1.IngressServiceBackend) (apiv1.ServicePort, error) {
+	svc := s.GetService(namespace, backend.Name)
+	if svc == nil {
+		if backend.Port.Name != "" {
+			return apiv1.ServicePort{}, fmt.Errorf("service %s not found, cannot resolve port %q", backend.Name, backend.Port.Name)
+		}
+		return apiv1.ServicePort{Port: backend.Port.Number}, nil
+	}
+
+	for _, port := range svc.Spec.Ports {
+		if backend.Port.Name != "" && port.Name == backend.Port.Name {
+			return port, nil
+		}
+		if backend.Port.Name == "" && port.Port == backend.Port.Number {
+			return port, nil
+		}
+	}
+
+	if backend.Port.Name != "" {
+		return apiv1.ServicePort{}, fmt.Errorf("service %s has no port named %q", backend.Name, backend.Port.Name)
+	}
+	// port numbers are used as is, ports of ExternalName services for instance are informative
+	return apiv1.ServicePort{Port: backend.Port.Number}, nil
+}
+
+// hasServicePort returns whether the service lists the port.
+func hasServicePort(svc *apiv1.Service, port apiv1.ServicePort) bool {
+	return slices.ContainsFunc(svc.Spec.Ports, func(p apiv1.ServicePort) bool {
+		return p.Name == port.Name && p.Port == port.Port
+	})
+}
+
+// serviceExternalName returns the external name of an ExternalName service, or an empty string
+// for other services.
+func serviceExternalName(s *store.Store, namespace, name string) string {
+	svc := s.GetService(namespace, name)
+	if svc == nil || svc.Spec.Type != apiv1.ServiceTypeExternalName {
+		return ""
+	}
+	return svc.Spec.ExternalName
+}
+
+// isEndpointReady returns whether the endpoint can get new requests.
+func isEndpointReady(ep discoveryv1.Endpoint) bool {
+	return ep.Conditions.Ready == nil || *ep.Conditions.Ready
+}
+
+// isEndpointServing returns whether the endpoint can still serve requests.
+func isEndpointServing(ep discoveryv1.Endpoint) bool {
+	if ep.Conditions.Serving == nil {
+		return isEndpointReady(ep)
+	}
+	return *ep.Conditions.Serving
+}
+
+// isEndpointTerminating returns whether the pod of the endpoint is shutting down.
+func isEndpointTerminating(ep discoveryv1.Endpoint) bool {
+	return ep.Conditions.Terminating != nil && *ep.Conditions.Terminating
+}
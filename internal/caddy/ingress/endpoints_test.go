@@ -0,0 +1,381 @@
+package ingress
+
+import (
+	"testing"
+
+	"github.com/caddyserver/ingress/pkg/store"
+	"github.com/stretchr/testify/require"
+	apiv1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+)
+
+func endpoint(addr string, ready, serving, terminating bool) discoveryv1.Endpoint {
+	return discoveryv1.Endpoint{
+		Addresses: []string{addr},
+		Conditions: discoveryv1.EndpointConditions{
+			Ready:       ptr.To(ready),
+			Serving:     ptr.To(serving),
+			Terminating: ptr.To(terminating),
+		},
+	}
+}
+
+func TestPodUpstreams(t *testing.T) {
+	httpPort := []discoveryv1.EndpointPort{{Name: ptr.To("http"), Port: ptr.To[int32](8080)}}
+
+	testCases := []struct {
+		desc     string
+		slices   []*discoveryv1.EndpointSlice
+		portName string
+		family   apiv1.IPFamily
+		expected []string
+	}{
+		{
+			desc:     "no endpoints",
+			portName: "http",
+			expected: []string{},
+		},
+		{
+			desc: "ready endpoints of all slices",
+			slices: []*discoveryv1.EndpointSlice{
+				{
+					AddressType: discoveryv1.AddressTypeIPv4,
+					Ports:       httpPort,
+					Endpoints: []discoveryv1.Endpoint{
+						endpoint("10.0.0.2", true, true, false),
+						endpoint("10.0.0.1", true, true, false),
+					},
+				},
+				{
+					AddressType: discoveryv1.AddressTypeIPv4,
+					Ports:       httpPort,
+					Endpoints:   []discoveryv1.Endpoint{endpoint("10.0.0.3", true, true, false)},
+				},
+			},
+			portName: "http",
+			expected: []string{"10.0.0.1:8080", "10.0.0.2:8080", "10.0.0.3:8080"},
+		},
+		{
+			desc: "pods of a dual-stack service are used once",
+			// the same pod in the slice of each family
+			slices: []*discoveryv1.EndpointSlice{
+				{
+					AddressType: discoveryv1.AddressTypeIPv4,
+					Ports:       httpPort,
+					Endpoints:   []discoveryv1.Endpoint{endpoint("10.0.0.1", true, true, false)},
+				},
+				{
+					AddressType: discoveryv1.AddressTypeIPv6,
+					Ports:       httpPort,
+					Endpoints:   []discoveryv1.Endpoint{endpoint("fd00::1", true, true, false)},
+				},
+			},
+			portName: "http",
+			expected: []string{"10.0.0.1:8080"},
+		},
+		{
+			desc: "endpoints of an IP family",
+			slices: []*discoveryv1.EndpointSlice{
+				{
+					AddressType: discoveryv1.AddressTypeIPv4,
+					Ports:       httpPort,
+					Endpoints:   []discoveryv1.Endpoint{endpoint("10.0.0.1", true, true, false)},
+				},
+				{
+					AddressType: discoveryv1.AddressTypeIPv6,
+					Ports:       httpPort,
+					Endpoints:   []discoveryv1.Endpoint{endpoint("fd00::1", true, true, false)},
+				},
+			},
+			portName: "http",
+			family:   apiv1.IPv6Protocol,
+			expected: []string{"[fd00::1]:8080"},
+		},
+		{
+			desc: "terminating endpoints are drained",
+			slices: []*discoveryv1.EndpointSlice{{
+				AddressType: discoveryv1.AddressTypeIPv4,
+				Ports:       httpPort,
+				Endpoints: []discoveryv1.Endpoint{
+					endpoint("10.0.0.1", true, true, false),
+					endpoint("10.0.0.2", false, true, true),
+					endpoint("10.0.0.3", false, false, false),
+				},
+			}},
+			portName: "http",
+			expected: []string{"10.0.0.1:8080"},
+		},
+		{
+			desc: "serving terminating endpoints are used when none is ready",
+			slices: []*discoveryv1.EndpointSlice{{
+				AddressType: discoveryv1.AddressTypeIPv4,
+				Ports:       httpPort,
+				Endpoints: []discoveryv1.Endpoint{
+					endpoint("10.0.0.2", false, true, true),
+					endpoint("10.0.0.3", false, false, true),
+				},
+			}},
+			portName: "http",
+			expected: []string{"10.0.0.2:8080"},
+		},
+		{
+			desc: "port of a service with several ports",
+			slices: []*discoveryv1.EndpointSlice{{
+				AddressType: discoveryv1.AddressTypeIPv4,
+				Ports: []discoveryv1.EndpointPort{
+					{Name: ptr.To("metrics"), Port: ptr.To[int32](9090)},
+					{Name: ptr.To("http"), Port: ptr.To[int32](8080)},
+				},
+				Endpoints: []discoveryv1.Endpoint{endpoint("10.0.0.1", true, true, false)},
+			}},
+			portName: "http",
+			expected: []string{"10.0.0.1:8080"},
+		},
+		{
+			desc: "unnamed port",
+			slices: []*discoveryv1.EndpointSlice{{
+				AddressType: discoveryv1.AddressTypeIPv4,
+				Ports:       []discoveryv1.EndpointPort{{Port: ptr.To[int32](8080)}},
+				Endpoints:   []discoveryv1.Endpoint{endpoint("10.0.0.1", true, true, false)},
+			}},
+			expected: []string{"10.0.0.1:8080"},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			dials := []string{}
+			for _, u := range podUpstreams(tC.slices, tC.portName, tC.family) {
+				dials = append(dials, u.Dial)
+			}
+			require.Equal(t, tC.expected, dials)
+		})
+	}
+}
+
+func TestServicePort(t *testing.T) {
+	s := store.NewStore(store.Options{}, "", &store.PodInfo{})
+	s.AddService(&apiv1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "svc", Namespace: "default"},
+		Spec: apiv1.ServiceSpec{Ports: []apiv1.ServicePort{
+			{Name: "http", Port: 80, TargetPort: intstr.FromString("web")},
+			{Name: "metrics", Port: 9090, TargetPort: intstr.FromInt32(9090)},
+		}},
+	})
+	s.AddService(&apiv1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "external", Namespace: "default", UID: "external"},
+		Spec:       apiv1.ServiceSpec{Type: apiv1.ServiceTypeExternalName, ExternalName: "api.example.com"},
+	})
+
+	testCases := []struct {
+		desc          string
+		backend       networkingv1.IngressServiceBackend
+		expectedPort  int32
+		expectedName  string
+		expectedError string
+	}{
+		{
+			desc:         "port name",
+			backend:      networkingv1.IngressServiceBackend{Name: "svc", Port: networkingv1.ServiceBackendPort{Name: "metrics"}},
+			expectedPort: 9090,
+			expectedName: "metrics",
+		},
+		{
+			desc:         "port number",
+			backend:      networkingv1.IngressServiceBackend{Name: "svc", Port: networkingv1.ServiceBackendPort{Number: 80}},
+			expectedPort: 80,
+			expectedName: "http",
+		},
+		{
+			desc:          "unknown port name",
+			backend:       networkingv1.IngressServiceBackend{Name: "svc", Port: networkingv1.ServiceBackendPort{Name: "admin"}},
+			expectedError: `service svc has no port named "admin"`,
+		},
+		{
+			desc:         "port number the service does not list",
+			backend:      networkingv1.IngressServiceBackend{Name: "svc", Port: networkingv1.ServiceBackendPort{Number: 8080}},
+			expectedPort: 8080,
+		},
+		{
+			desc:         "port number of an unknown service",
+			backend:      networkingv1.IngressServiceBackend{Name: "other", Port: networkingv1.ServiceBackendPort{Number: 8080}},
+			expectedPort: 8080,
+		},
+		{
+			desc:          "port name of an unknown service",
+			backend:       networkingv1.IngressServiceBackend{Name: "other", Port: networkingv1.ServiceBackendPort{Name: "http"}},
+			expectedError: `service other not found, cannot resolve port "http"`,
+		},
+		{
+			desc:         "port number of an ExternalName service",
+			backend:      networkingv1.IngressServiceBackend{Name: "external", Port: networkingv1.ServiceBackendPort{Number: 443}},
+			expectedPort: 443,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			port, err := servicePort(s, "default", &tC.backend)
+			if tC.expectedError != "" {
+				require.EqualError(t, err, tC.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tC.expectedPort, port.Port)
+			require.Equal(t, tC.expectedName, port.Name)
+		})
+	}
+}
+
+func TestServiceUpstreams(t *testing.T) {
+	services := []*apiv1.Service{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "svc", Namespace: "default", UID: "svc"},
+			Spec: apiv1.ServiceSpec{
+				ClusterIP:  "10.96.0.10",
+				ClusterIPs: []string{"10.96.0.10", "fd00:10:96::a"},
+				IPFamilies: []apiv1.IPFamily{apiv1.IPv4Protocol, apiv1.IPv6Protocol},
+				Ports:      []apiv1.ServicePort{{Name: "http", Port: 80}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "headless", Namespace: "default", UID: "headless"},
+			Spec:       apiv1.ServiceSpec{ClusterIP: apiv1.ClusterIPNone},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "single", Namespace: "default", UID: "single"},
+			Spec: apiv1.ServiceSpec{
+				ClusterIP:  "10.96.0.11",
+				ClusterIPs: []string{"10.96.0.11"},
+				IPFamilies: []apiv1.IPFamily{apiv1.IPv4Protocol},
+			},
+		},
+	}
+	slices := []*discoveryv1.EndpointSlice{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "svc-abc",
+				Namespace: "default",
+				UID:       "svc-abc",
+				Labels:    map[string]string{discoveryv1.LabelServiceName: "svc"},
+			},
+			AddressType: discoveryv1.AddressTypeIPv4,
+			Ports:       []discoveryv1.EndpointPort{{Name: ptr.To("http"), Port: ptr.To[int32](8080)}},
+			Endpoints:   []discoveryv1.Endpoint{endpoint("10.0.0.1", true, true, false)},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "svc-def",
+				Namespace: "default",
+				UID:       "svc-def",
+				Labels:    map[string]string{discoveryv1.LabelServiceName: "svc"},
+			},
+			AddressType: discoveryv1.AddressTypeIPv6,
+			Ports:       []discoveryv1.EndpointPort{{Name: ptr.To("http"), Port: ptr.To[int32](8080)}},
+			Endpoints:   []discoveryv1.Endpoint{endpoint("fd00::1", true, true, false)},
+		},
+	}
+
+	testCases := []struct {
+		desc          string
+		config        *store.ConfigMapOptions
+		service       string
+		port          *apiv1.ServicePort
+		expected      string
+		expectedError string
+	}{
+		{
+			desc:     "dns",
+			service:  "svc",
+			expected: "svc.default.svc.cluster.local:80",
+		},
+		{
+			desc:     "dns with a cluster domain",
+			config:   &store.ConfigMapOptions{ClusterDomain: "k8s.example.com"},
+			service:  "svc",
+			expected: "svc.default.svc.k8s.example.com:80",
+		},
+		{
+			desc:     "cluster IP",
+			config:   &store.ConfigMapOptions{UpstreamAddressing: store.UpstreamAddressingClusterIP},
+			service:  "svc",
+			expected: "10.96.0.10:80",
+		},
+		{
+			desc:     "cluster IP of an IP family",
+			config:   &store.ConfigMapOptions{UpstreamAddressing: store.UpstreamAddressingClusterIP, UpstreamIPFamily: "IPv6"},
+			service:  "svc",
+			expected: "[fd00:10:96::a]:80",
+		},
+		{
+			desc:          "missing cluster IP of an IP family",
+			config:        &store.ConfigMapOptions{UpstreamAddressing: store.UpstreamAddressingClusterIP, UpstreamIPFamily: "IPv6"},
+			service:       "single",
+			expectedError: "service single has no IPv6 cluster IP",
+		},
+		{
+			desc:     "cluster IP of a headless service",
+			config:   &store.ConfigMapOptions{UpstreamAddressing: store.UpstreamAddressingClusterIP},
+			service:  "headless",
+			expected: "headless.default.svc.cluster.local:80",
+		},
+		{
+			desc:     "cluster IP of an unknown service",
+			config:   &store.ConfigMapOptions{UpstreamAddressing: store.UpstreamAddressingClusterIP},
+			service:  "other",
+			expected: "other.default.svc.cluster.local:80",
+		},
+		{
+			desc:     "endpoints of the primary IP family",
+			config:   &store.ConfigMapOptions{UpstreamAddressing: store.UpstreamAddressingEndpoints},
+			service:  "svc",
+			expected: "10.0.0.1:8080",
+		},
+		{
+			desc:     "endpoints of an IP family",
+			config:   &store.ConfigMapOptions{UpstreamAddressing: store.UpstreamAddressingEndpoints, UpstreamIPFamily: "IPv6"},
+			service:  "svc",
+			expected: "[fd00::1]:8080",
+		},
+		{
+			desc:     "endpoints of a port the service does not list",
+			config:   &store.ConfigMapOptions{UpstreamAddressing: store.UpstreamAddressingEndpoints},
+			service:  "svc",
+			port:     &apiv1.ServicePort{Port: 8081},
+			expected: "svc.default.svc.cluster.local:8081",
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			s := store.NewStore(store.Options{}, "", &store.PodInfo{})
+			s.ConfigMap = tC.config
+			for _, svc := range services {
+				s.AddService(svc)
+			}
+			for _, slice := range slices {
+				s.AddEndpointSlice(slice)
+			}
+
+			ing := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "ing", Namespace: "default"}}
+			backend := &networkingv1.IngressServiceBackend{Name: tC.service}
+			port := apiv1.ServicePort{Name: "http", Port: 80}
+			if tC.port != nil {
+				port = *tC.port
+			}
+
+			upstreams, err := serviceUpstreams(s, ing, backend, port)
+			if tC.expectedError != "" {
+				require.EqualError(t, err, tC.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, upstreams, 1)
+			require.Equal(t, tC.expected, upstreams[0].Dial)
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package ingress
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp/reverseproxy"
+	v1 "k8s.io/api/networking/v1"
+)
+
+// healthChecks returns the health checks of the reverse proxy of an ingress,
+// or nil if its annotations configure none.
+func healthChecks(ing *v1.Ingress) (*reverseproxy.HealthChecks, error) {
+	active, err := activeHealthChecks(ing)
+	if err != nil {
+		return nil, err
+	}
+	passive, err := passiveHealthChecks(ing)
+	if err != nil {
+		return nil, err
+	}
+
+	if active == nil && passive == nil {
+		return nil, nil
+	}
+	return &reverseproxy.HealthChecks{Active: active, Passive: passive}, nil
+}
+
+// activeHealthChecks returns the active health checks set by the health-check annotations.
+func activeHealthChecks(ing *v1.Ingress) (*reverseproxy.ActiveHealthChecks, error) {
+	a := &reverseproxy.ActiveHealthChecks{
+		URI:        getAnnotation(ing, healthCheckPath),
+		ExpectBody: getAnnotation(ing, healthCheckBody),
+	}
+
+	var err error
+	if a.Port, err = getAnnotationInt(ing, healthCheckPort); err != nil {
+		return nil, err
+	}
+	if a.Port < 0 || a.Port > 65535 {
+		return nil, fmt.Errorf("invalid annotation %s %q, expected a port number", healthCheckPort, getAnnotation(ing, healthCheckPort))
+	}
+	if a.Interval, err = getAnnotationDuration(ing, healthCheckInterval); err != nil {
+		return nil, err
+	}
+	if a.Timeout, err = getAnnotationDuration(ing, healthCheckTimeout); err != nil {
+		return nil, err
+	}
+	if a.Headers, err = getAnnotationHeaders(ing, healthCheckHeaders); err != nil {
+		return nil, err
+	}
+	if a.ExpectBody != "" {
+		if _, err := regexp.Compile(a.ExpectBody); err != nil {
+			return nil, fmt.Errorf("invalid annotation %s %q: %w", healthCheckBody, a.ExpectBody, err)
+		}
+	}
+	if status := getAnnotation(ing, healthCheckStatus); status != "" {
+		if a.ExpectStatus, err = parseStatusCode(status); err != nil {
+			return nil, fmt.Errorf("invalid annotation %s %q: %w", healthCheckStatus, status, err)
+		}
+	}
+
+	if a.URI == "" && a.Port == 0 {
+		if a.Interval != 0 || a.Timeout != 0 || a.Headers != nil || a.ExpectBody != "" || a.ExpectStatus != 0 {
+			return nil, fmt.Errorf("annotation %s or %s is required by active health checks", healthCheckPath, healthCheckPort)
+		}
+		return nil, nil
+	}
+	return a, nil
+}
+
+// passiveHealthChecks returns the passive health checks set by the health-check annotations.
+func passiveHealthChecks(ing *v1.Ingress) (*reverseproxy.PassiveHealthChecks, error) {
+	p := &reverseproxy.PassiveHealthChecks{}
+
+	var err error
+	if p.FailDuration, err = getAnnotationDuration(ing, healthCheckFailDuration); err != nil {
+		return nil, err
+	}
+	if p.MaxFails, err = getAnnotationInt(ing, healthCheckMaxFails); err != nil {
+		return nil, err
+	}
+	if p.MaxFails < 0 {
+		return nil, fmt.Errorf("invalid annotation %s %q, expected a positive number", healthCheckMaxFails, getAnnotation(ing, healthCheckMaxFails))
+	}
+	if p.UnhealthyLatency, err = getAnnotationDuration(ing, healthCheckUnhealthyLatency); err != nil {
+		return nil, err
+	}
+	if statuses := getAnnotation(ing, healthCheckUnhealthyStatus); statuses != "" {
+		for _, s := range strings.Split(statuses, ",") {
+			status, err := parseStatusCode(strings.TrimSpace(s))
+			if err != nil {
+				return nil, fmt.Errorf("invalid annotation %s %q: %w", healthCheckUnhealthyStatus, statuses, err)
+			}
+			p.UnhealthyStatus = append(p.UnhealthyStatus, status)
+		}
+	}
+
+	// failures are only counted for the fail duration
+	if p.FailDuration == 0 {
+		if p.MaxFails != 0 || p.UnhealthyLatency != 0 || p.UnhealthyStatus != nil {
+			return nil, fmt.Errorf("annotation %s is required by passive health checks", healthCheckFailDuration)
+		}
+		return nil, nil
+	}
+	return p, nil
+}
+
+// parseStatusCode parses an HTTP status code, or a class of status codes like 5xx as its first digit.
+func parseStatusCode(s string) (int, error) {
+	if len(s) == 3 && strings.HasSuffix(s, "xx") {
+		s = s[:1]
+	}
+	code, err := strconv.Atoi(s)
+	if err != nil || code < 1 || (code > 5 && code < 100) || code > 599 {
+		return 0, fmt.Errorf("expected a status code like 200 or 2xx")
+	}
+	return code, nil
+}
+
+// getAnnotationInt returns the integer value of an annotation, or 0 if it is not set.
+func getAnnotationInt(ing *v1.Ingress, rule string) (int, error) {
+	val := getAnnotation(ing, rule)
+	if val == "" {
+		return 0, nil
+	}
+	i, err := strconv.Atoi(strings.TrimSpace(val))
+	if err != nil {
+		return 0, fmt.Errorf("invalid annotation %s %q, expected a number", rule, val)
+	}
+	return i, nil
+}
+
+// getAnnotationHeaders returns the headers of an annotation listing comma separated
+// "Name: value" pairs, or nil if it is not set.
+func getAnnotationHeaders(ing *v1.Ingress, rule string) (http.Header, error) {
+	val := getAnnotation(ing, rule)
+	if val == "" {
+		return nil, nil
+	}
+	headers := http.Header{}
+	for _, pair := range strings.Split(val, ",") {
+		name, value, ok := strings.Cut(pair, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid annotation %s %q, expected comma separated Name: value headers", rule, val)
+		}
+		headers.Add(name, strings.TrimSpace(value))
+	}
+	return headers, nil
+}
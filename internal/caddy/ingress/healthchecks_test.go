@@ -0,0 +1,166 @@
+package ingress
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestHealthChecks(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		expected    string
+	}{
+		{
+			name:     "no health checks",
+			expected: `null`,
+		},
+		{
+			name: "active health checks",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/health-check-path":     "/healthz?full=1",
+				"caddy.ingress.kubernetes.io/health-check-port":     "8081",
+				"caddy.ingress.kubernetes.io/health-check-interval": "10s",
+				"caddy.ingress.kubernetes.io/health-check-timeout":  "2s",
+				"caddy.ingress.kubernetes.io/health-check-status":   "2xx",
+				"caddy.ingress.kubernetes.io/health-check-body":     "^ok$",
+				"caddy.ingress.kubernetes.io/health-check-headers":  "Host: internal.example.com, X-Probe: caddy",
+			},
+			expected: `{"active": {
+				"uri": "/healthz?full=1",
+				"port": 8081,
+				"interval": 10000000000,
+				"timeout": 2000000000,
+				"expect_status": 2,
+				"expect_body": "^ok$",
+				"headers": {"Host": ["internal.example.com"], "X-Probe": ["caddy"]}
+			}}`,
+		},
+		{
+			name: "passive health checks",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/health-check-fail-duration":     "30s",
+				"caddy.ingress.kubernetes.io/health-check-max-fails":         "3",
+				"caddy.ingress.kubernetes.io/health-check-unhealthy-status":  "502, 5xx",
+				"caddy.ingress.kubernetes.io/health-check-unhealthy-latency": "5s",
+			},
+			expected: `{"passive": {
+				"fail_duration": 30000000000,
+				"max_fails": 3,
+				"unhealthy_status": [502, 5],
+				"unhealthy_latency": 5000000000
+			}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ing := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Annotations: test.annotations}}
+
+			hc, err := healthChecks(ing)
+			require.NoError(t, err)
+
+			cfgJSON, err := json.Marshal(hc)
+			require.NoError(t, err)
+			require.JSONEq(t, test.expected, string(cfgJSON))
+		})
+	}
+}
+
+func TestMisconfiguredHealthChecks(t *testing.T) {
+	tests := []struct {
+		name          string
+		annotations   map[string]string
+		expectedError string
+	}{
+		{
+			name: "invalid port",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/health-check-port": "http",
+			},
+			expectedError: `invalid annotation health-check-port "http", expected a number`,
+		},
+		{
+			name: "out of range port",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/health-check-port": "70000",
+			},
+			expectedError: `invalid annotation health-check-port "70000", expected a port number`,
+		},
+		{
+			name: "invalid interval",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/health-check-path":     "/healthz",
+				"caddy.ingress.kubernetes.io/health-check-interval": "often",
+			},
+			expectedError: `invalid annotation health-check-interval "often"`,
+		},
+		{
+			name: "invalid status",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/health-check-path":   "/healthz",
+				"caddy.ingress.kubernetes.io/health-check-status": "ok",
+			},
+			expectedError: `invalid annotation health-check-status "ok": expected a status code like 200 or 2xx`,
+		},
+		{
+			name: "invalid body",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/health-check-path": "/healthz",
+				"caddy.ingress.kubernetes.io/health-check-body": "(",
+			},
+			expectedError: `invalid annotation health-check-body "("`,
+		},
+		{
+			name: "invalid headers",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/health-check-path":    "/healthz",
+				"caddy.ingress.kubernetes.io/health-check-headers": "X-Probe",
+			},
+			expectedError: `invalid annotation health-check-headers "X-Probe", expected comma separated Name: value headers`,
+		},
+		{
+			name: "active health checks without path or port",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/health-check-interval": "10s",
+			},
+			expectedError: "annotation health-check-path or health-check-port is required by active health checks",
+		},
+		{
+			name: "invalid unhealthy status",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/health-check-fail-duration":    "30s",
+				"caddy.ingress.kubernetes.io/health-check-unhealthy-status": "500,99",
+			},
+			expectedError: `invalid annotation health-check-unhealthy-status "500,99": expected a status code like 200 or 2xx`,
+		},
+		{
+			name: "negative max fails",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/health-check-fail-duration": "30s",
+				"caddy.ingress.kubernetes.io/health-check-max-fails":     "-1",
+			},
+			expectedError: `invalid annotation health-check-max-fails "-1", expected a positive number`,
+		},
+		{
+			name: "passive health checks without fail duration",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/health-check-max-fails": "3",
+			},
+			expectedError: "annotation health-check-fail-duration is required by passive health checks",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ing := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Annotations: test.annotations}}
+
+			_, err := healthChecks(ing)
+			require.ErrorContains(t, err, test.expectedError)
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package ingress
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp/reverseproxy"
+	v1 "k8s.io/api/networking/v1"
+)
+
+// loadBalancing returns the load balancing config of the reverse proxy of an ingress,
+// or nil if its annotations keep the defaults of caddy.
+func loadBalancing(ing *v1.Ingress) (*reverseproxy.LoadBalancing, error) {
+	lb := &reverseproxy.LoadBalancing{}
+
+	policy, err := selectionPolicy(ing)
+	if err != nil {
+		return nil, err
+	}
+	lb.SelectionPolicyRaw = policy
+
+	if lb.TryDuration, err = getAnnotationDuration(ing, lbTryDuration); err != nil {
+		return nil, err
+	}
+	if lb.TryInterval, err = getAnnotationDuration(ing, lbTryInterval); err != nil {
+		return nil, err
+	}
+	if lb.Retries, err = getAnnotationInt(ing, lbRetries); err != nil {
+		return nil, err
+	}
+	if lb.Retries < 0 {
+		return nil, fmt.Errorf("invalid annotation %s %q, expected a positive number", lbRetries, getAnnotation(ing, lbRetries))
+	}
+	if lb.RetryMatchRaw, err = retryMatch(ing); err != nil {
+		return nil, err
+	}
+	if lb.RetryMatchRaw != nil && lb.Retries == 0 && lb.TryDuration == 0 {
+		return nil, fmt.Errorf("annotation %s or %s is required to retry requests", lbRetries, lbTryDuration)
+	}
+
+	if lb.SelectionPolicyRaw == nil && lb.TryDuration == 0 && lb.TryInterval == 0 && lb.Retries == 0 {
+		return nil, nil
+	}
+	return lb, nil
+}
+
+// selectionPolicy returns the selection policy module set by the lb-policy annotation.
+func selectionPolicy(ing *v1.Ingress) (json.RawMessage, error) {
+	var policy any
+	switch name := getAnnotation(ing, lbPolicy); name {
+	case "":
+		return nil, nil
+	case "round_robin":
+		policy = reverseproxy.RoundRobinSelection{}
+	case "least_conn":
+		policy = reverseproxy.LeastConnSelection{}
+	case "ip_hash":
+		policy = reverseproxy.IPHashSelection{}
+	case "uri_hash":
+		policy = reverseproxy.URIHashSelection{}
+	case "first":
+		policy = reverseproxy.FirstSelection{}
+	case "header":
+		field := getAnnotation(ing, lbPolicyHeader)
+		if field == "" {
+			return nil, fmt.Errorf("annotation %s is required by the header lb-policy", lbPolicyHeader)
+		}
+		policy = reverseproxy.HeaderHashSelection{Field: field}
+	case "cookie":
+		maxAge, err := getAnnotationDuration(ing, lbPolicyCookieTTL)
+		if err != nil {
+			return nil, err
+		}
+		policy = reverseproxy.CookieHashSelection{
+			Name:   getAnnotation(ing, lbPolicyCookieName),
+			Secret: getAnnotation(ing, lbPolicyCookieSecret),
+			MaxAge: maxAge,
+		}
+	default:
+		return nil, fmt.Errorf("invalid annotation %s %q, expected one of round_robin, least_conn, ip_hash, uri_hash, header, cookie or first", lbPolicy, name)
+	}
+	return caddyconfig.JSONModuleObject(policy, "policy", getAnnotation(ing, lbPolicy), nil), nil
+}
+
+// retryMatch returns the matcher sets of the requests retried on another upstream, or nil to
+// retry GET requests on connection errors like caddy does by default.
+//
+// Requests failing to connect are always retried. Connection errors after that are retried
+// for the retryable methods, and upstream responses with a status code listed in lb-retry-on.
+func retryMatch(ing *v1.Ingress) (caddyhttp.RawMatcherSets, error) {
+	methodsVal, retryOn := getAnnotation(ing, lbRetryMethods), getAnnotation(ing, lbRetryOn)
+	if methodsVal == "" && retryOn == "" {
+		return nil, nil
+	}
+
+	methods := caddyhttp.MatchMethod{http.MethodGet}
+	if methodsVal != "" {
+		methods = nil
+		for _, m := range strings.Split(methodsVal, ",") {
+			m = strings.ToUpper(strings.TrimSpace(m))
+			if m == "" {
+				return nil, fmt.Errorf("invalid annotation %s %q, expected comma separated methods", lbRetryMethods, methodsVal)
+			}
+			methods = append(methods, m)
+		}
+	}
+
+	onError := retryOn == ""
+	var codes []string
+	var classes []int
+	for _, cond := range strings.FieldsFunc(retryOn, func(r rune) bool { return r == ',' }) {
+		cond = strings.TrimSpace(cond)
+		if cond == "error" {
+			onError = true
+			continue
+		}
+		status, err := parseStatusCode(cond)
+		if err != nil {
+			return nil, fmt.Errorf("invalid annotation %s %q, expected error or status codes like 502 or 5xx", lbRetryOn, retryOn)
+		}
+		if status < 100 {
+			classes = append(classes, status)
+		} else {
+			codes = append(codes, strconv.Itoa(status))
+		}
+	}
+	if !onError && len(codes) == 0 && len(classes) == 0 {
+		return nil, fmt.Errorf("invalid annotation %s %q, expected error or status codes like 502 or 5xx", lbRetryOn, retryOn)
+	}
+
+	var sets caddyhttp.RawMatcherSets
+	if onError {
+		sets = append(sets, caddy.ModuleMap{
+			"method": caddyconfig.JSON(methods, nil),
+		})
+	}
+	if len(codes) > 0 || len(classes) > 0 {
+		sets = append(sets, caddy.ModuleMap{
+			"method":     caddyconfig.JSON(methods, nil),
+			"expression": caddyconfig.JSON(retryStatusExpression(codes, classes), nil),
+		})
+	}
+	return sets, nil
+}
+
+// retryStatusExpression returns the CEL expression matching upstream responses with one of
+// the status codes or classes of status codes.
+//
+// The status code of the last response is still set when a retry fails with a connection
+// error, and is not set when the first attempt does, so the expression checks both.
+func retryStatusExpression(codes []string, classes []int) string {
+	const status = "{http.reverse_proxy.status_code}"
+
+	var alternatives []string
+	if len(codes) > 0 {
+		alternatives = append(alternatives, status+" in ["+strings.Join(codes, ", ")+"]")
+	}
+	for _, c := range classes {
+		alternatives = append(alternatives, fmt.Sprintf("(type(%s) == int && %s >= %d && %s < %d)", status, status, c*100, status, (c+1)*100))
+	}
+	return "{http.reverse_proxy.is_transport_error} != true && (" + strings.Join(alternatives, " || ") + ")"
+}
+
+// getAnnotationDuration returns the duration of an annotation, or 0 if it is not set.
+func getAnnotationDuration(ing *v1.Ingress, rule string) (caddy.Duration, error) {
+	val := getAnnotation(ing, rule)
+	if val == "" {
+		return 0, nil
+	}
+	d, err := caddy.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid annotation %s %q: %w", rule, val, err)
+	}
+	return caddy.Duration(d), nil
+}
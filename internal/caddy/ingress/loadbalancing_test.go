@@ -0,0 +1,187 @@
+package ingress
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLoadBalancing(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		expected    string
+	}{
+		{
+			name:     "defaults",
+			expected: `null`,
+		},
+		{
+			name: "least connections",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/lb-policy": "least_conn",
+			},
+			expected: `{"selection_policy": {"policy": "least_conn"}}`,
+		},
+		{
+			name: "header",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/lb-policy":        "header",
+				"caddy.ingress.kubernetes.io/lb-policy-header": "X-User",
+			},
+			expected: `{"selection_policy": {"policy": "header", "field": "X-User"}}`,
+		},
+		{
+			name: "cookie",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/lb-policy":               "cookie",
+				"caddy.ingress.kubernetes.io/lb-policy-cookie-name":   "affinity",
+				"caddy.ingress.kubernetes.io/lb-policy-cookie-secret": "s3cr3t",
+				"caddy.ingress.kubernetes.io/lb-policy-cookie-ttl":    "1h",
+			},
+			expected: `{"selection_policy": {"policy": "cookie", "name": "affinity", "secret": "s3cr3t", "max_age": 3600000000000}}`,
+		},
+		{
+			name: "retries without policy",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/lb-try-duration": "5s",
+				"caddy.ingress.kubernetes.io/lb-try-interval": "250ms",
+			},
+			expected: `{"try_duration": 5000000000, "try_interval": 250000000}`,
+		},
+		{
+			name: "retries on connection errors",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/lb-retries": "2",
+			},
+			expected: `{"retries": 2}`,
+		},
+		{
+			name: "retries of methods on connection errors and status codes",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/lb-retries":       "3",
+				"caddy.ingress.kubernetes.io/lb-retry-methods": "get, put",
+				"caddy.ingress.kubernetes.io/lb-retry-on":      "error, 502, 503, 5xx",
+			},
+			expected: `{
+				"retries": 3,
+				"retry_match": [
+					{"method": ["GET", "PUT"]},
+					{
+						"method": ["GET", "PUT"],
+						"expression": "{http.reverse_proxy.is_transport_error} != true && ({http.reverse_proxy.status_code} in [502, 503] || (type({http.reverse_proxy.status_code}) == int && {http.reverse_proxy.status_code} >= 500 && {http.reverse_proxy.status_code} < 600))"
+					}
+				]
+			}`,
+		},
+		{
+			name: "retries of GET requests on status codes only",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/lb-try-duration": "10s",
+				"caddy.ingress.kubernetes.io/lb-retry-on":     "503",
+			},
+			expected: `{
+				"try_duration": 10000000000,
+				"retry_match": [
+					{
+						"method": ["GET"],
+						"expression": "{http.reverse_proxy.is_transport_error} != true && ({http.reverse_proxy.status_code} in [503])"
+					}
+				]
+			}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ing := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Annotations: test.annotations}}
+
+			lb, err := loadBalancing(ing)
+			require.NoError(t, err)
+
+			cfgJSON, err := json.Marshal(lb)
+			require.NoError(t, err)
+			require.JSONEq(t, test.expected, string(cfgJSON))
+		})
+	}
+}
+
+func TestMisconfiguredLoadBalancing(t *testing.T) {
+	tests := []struct {
+		name          string
+		annotations   map[string]string
+		expectedError string
+	}{
+		{
+			name: "unknown policy",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/lb-policy": "sticky",
+			},
+			expectedError: `invalid annotation lb-policy "sticky", expected one of round_robin, least_conn, ip_hash, uri_hash, header, cookie or first`,
+		},
+		{
+			name: "header policy without header",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/lb-policy": "header",
+			},
+			expectedError: "annotation lb-policy-header is required by the header lb-policy",
+		},
+		{
+			name: "invalid cookie ttl",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/lb-policy":            "cookie",
+				"caddy.ingress.kubernetes.io/lb-policy-cookie-ttl": "forever",
+			},
+			expectedError: `invalid annotation lb-policy-cookie-ttl "forever"`,
+		},
+		{
+			name: "invalid try duration",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/lb-try-duration": "5",
+			},
+			expectedError: `invalid annotation lb-try-duration "5"`,
+		},
+		{
+			name: "negative retries",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/lb-retries": "-1",
+			},
+			expectedError: `invalid annotation lb-retries "-1", expected a positive number`,
+		},
+		{
+			name: "invalid retry condition",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/lb-retries":  "3",
+				"caddy.ingress.kubernetes.io/lb-retry-on": "error, timeout",
+			},
+			expectedError: `invalid annotation lb-retry-on "error, timeout", expected error or status codes like 502 or 5xx`,
+		},
+		{
+			name: "invalid retry methods",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/lb-retries":       "3",
+				"caddy.ingress.kubernetes.io/lb-retry-methods": "GET,,PUT",
+			},
+			expectedError: `invalid annotation lb-retry-methods "GET,,PUT", expected comma separated methods`,
+		},
+		{
+			name: "retry conditions without retries",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/lb-retry-on": "502",
+			},
+			expectedError: "annotation lb-retries or lb-try-duration is required to retry requests",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ing := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Annotations: test.annotations}}
+
+			_, err := loadBalancing(ing)
+			require.ErrorContains(t, err, test.expectedError)
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package ingress
 
 import (
+	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/caddyserver/caddy/v2"
@@ -10,6 +12,10 @@ import (
 	v1 "k8s.io/api/networking/v1"
 )
 
+// pathRegexpName is the name of regular expression path matchers, captures are
+// available as {http.regexp.path.N} placeholders.
+const pathRegexpName = "path"
+
 type MatcherPlugin struct{}
 
 func (p MatcherPlugin) IngressPlugin() converter.PluginInfo {
@@ -42,6 +48,17 @@ func (p MatcherPlugin) IngressHandler(input converter.IngressMiddlewareInput) (*
 			} else {
 				match["path"] = caddyconfig.JSON(caddyhttp.MatchPath{pathPattern, pathPattern + "/*"}, nil)
 			}
+		} else if *input.Path.PathType == v1.PathTypeImplementationSpecific && getAnnotationBool(input.Ingress, useRegex, false) {
+			// regular expressions match from the start of the path
+			if !strings.HasPrefix(pathPattern, "^") {
+				pathPattern = "^" + pathPattern
+			}
+			if _, err := regexp.Compile(pathPattern); err != nil {
+				return nil, fmt.Errorf("invalid regular expression path %q: %w", input.Path.Path, err)
+			}
+			match["path_regexp"] = caddyconfig.JSON(caddyhttp.MatchPathRE{
+				MatchRegexp: caddyhttp.MatchRegexp{Name: pathRegexpName, Pattern: pathPattern},
+			}, nil)
 		} else {
 			match["path"] = caddyconfig.JSON(caddyhttp.MatchPath{pathPattern}, nil)
 		}
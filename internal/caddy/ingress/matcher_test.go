@@ -134,3 +134,158 @@ func TestPrefixPathDoesNotMatchSiblingPrefix(t *testing.T) {
 		require.Equalf(t, c.want, got, "path %q", c.path)
 	}
 }
+
+func TestRegexpPathMatcherConvertToCaddyConfig(t *testing.T) {
+	mp := MatcherPlugin{}
+
+	tests := []struct {
+		name          string
+		path          string
+		pathType      *networkingv1.PathType
+		expectedMatch string
+		expectedError string
+	}{
+		{
+			name:          "implementation specific path is a regular expression",
+			path:          "/api/(v1|v2)/(.*)",
+			pathType:      pathType(networkingv1.PathTypeImplementationSpecific),
+			expectedMatch: `{"path_regexp":{"name":"path","pattern":"^/api/(v1|v2)/(.*)"}}`,
+		},
+		{
+			name:          "anchored regular expression is kept",
+			path:          "^/[a-z]+$",
+			pathType:      pathType(networkingv1.PathTypeImplementationSpecific),
+			expectedMatch: `{"path_regexp":{"name":"path","pattern":"^/[a-z]+$"}}`,
+		},
+		{
+			name:          "exact path is not a regular expression",
+			path:          "/api/(.*)",
+			pathType:      pathType(networkingv1.PathTypeExact),
+			expectedMatch: `{"path":["/api/(.*)"]}`,
+		},
+		{
+			name:          "invalid regular expression",
+			path:          "/api/(.*",
+			pathType:      pathType(networkingv1.PathTypeImplementationSpecific),
+			expectedError: "invalid regular expression path \"/api/(.*\": error parsing regexp: missing closing ): `^/api/(.*`",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			input := converter.IngressMiddlewareInput{
+				Ingress: &networkingv1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: map[string]string{
+							"caddy.ingress.kubernetes.io/disable-ssl-redirect": "true",
+							"caddy.ingress.kubernetes.io/use-regex":            "true",
+						},
+					},
+				},
+				Path: networkingv1.HTTPIngressPath{
+					Path:     test.path,
+					PathType: test.pathType,
+				},
+				Route: &caddyhttp.Route{},
+			}
+
+			route, err := mp.IngressHandler(input)
+			if test.expectedError != "" {
+				require.EqualError(t, err, test.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, route.MatcherSetsRaw, 1)
+
+			matchJSON, err := json.Marshal(route.MatcherSetsRaw[0])
+			require.NoError(t, err)
+			require.JSONEq(t, test.expectedMatch, string(matchJSON))
+		})
+	}
+}
+
+func TestRegexpPathCapturesArePlaceholders(t *testing.T) {
+	mp := MatcherPlugin{}
+
+	input := converter.IngressMiddlewareInput{
+		Ingress: &networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					"caddy.ingress.kubernetes.io/disable-ssl-redirect": "true",
+					"caddy.ingress.kubernetes.io/use-regex":            "true",
+				},
+			},
+		},
+		Path: networkingv1.HTTPIngressPath{
+			Path:     "/api/(v1|v2)/(.*)",
+			PathType: pathType(networkingv1.PathTypeImplementationSpecific),
+		},
+		Route: &caddyhttp.Route{},
+	}
+
+	route, err := mp.IngressHandler(input)
+	require.NoError(t, err)
+
+	var matchPath caddyhttp.MatchPathRE
+	require.NoError(t, json.Unmarshal(route.MatcherSetsRaw[0]["path_regexp"], &matchPath))
+	require.NoError(t, matchPath.Provision(caddy.Context{}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/users/1", nil)
+	repl := caddy.NewReplacer()
+	req = req.WithContext(context.WithValue(req.Context(), caddy.ReplacerCtxKey, repl))
+
+	got, err := matchPath.MatchWithError(req)
+	require.NoError(t, err)
+	require.True(t, got)
+	require.Equal(t, "/api/v2/users/1", repl.ReplaceAll("/api/{http.regexp.path.1}/{http.regexp.path.2}", ""))
+
+	req = httptest.NewRequest(http.MethodGet, "/v2/api/users", nil)
+	req = req.WithContext(context.WithValue(req.Context(), caddy.ReplacerCtxKey, caddy.NewReplacer()))
+	got, err = matchPath.MatchWithError(req)
+	require.NoError(t, err)
+	require.False(t, got)
+}
+
+func TestWildcardHostMatchesOneLabel(t *testing.T) {
+	mp := MatcherPlugin{}
+
+	input := converter.IngressMiddlewareInput{
+		Ingress: &networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					"caddy.ingress.kubernetes.io/disable-ssl-redirect": "true",
+				},
+			},
+		},
+		Rule:  networkingv1.IngressRule{Host: "*.example.com"},
+		Route: &caddyhttp.Route{},
+	}
+
+	route, err := mp.IngressHandler(input)
+	require.NoError(t, err)
+
+	var matchHost caddyhttp.MatchHost
+	require.NoError(t, json.Unmarshal(route.MatcherSetsRaw[0]["host"], &matchHost))
+	require.NoError(t, matchHost.Provision(caddy.Context{}))
+
+	cases := []struct {
+		host string
+		want bool
+	}{
+		{"foo.example.com", true},
+		{"FOO.example.com", true},
+		{"example.com", false},
+		{"foo.bar.example.com", false},
+		{"fooexample.com", false},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "http://"+c.host+"/", nil)
+		repl := caddy.NewReplacer()
+		req = req.WithContext(context.WithValue(req.Context(), caddy.ReplacerCtxKey, repl))
+
+		got, err := matchHost.MatchWithError(req)
+		require.NoError(t, err)
+		require.Equalf(t, c.want, got, "host %q", c.host)
+	}
+}
@@ -0,0 +1,99 @@
+package ingress
+
+import (
+	"fmt"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp/reverseproxy"
+	"github.com/caddyserver/ingress/pkg/store"
+	v1 "k8s.io/api/networking/v1"
+)
+
+// proxyDefaults returns the ConfigMap options holding the defaults of the proxy annotations.
+func proxyDefaults(s *store.Store) store.ConfigMapOptions {
+	if s.ConfigMap == nil {
+		return store.ConfigMapOptions{}
+	}
+	return *s.ConfigMap
+}
+
+// applyProxyTimeouts sets the timeouts and keepalive of the transport to upstreams from the
+// annotations of an ingress, or from the ConfigMap defaults.
+func applyProxyTimeouts(ing *v1.Ingress, defaults store.ConfigMapOptions, transport *reverseproxy.HTTPTransport) error {
+	var err error
+	if transport.DialTimeout, err = getAnnotationDurationOr(ing, proxyDialTimeout, defaults.ProxyDialTimeout); err != nil {
+		return err
+	}
+	if transport.ResponseHeaderTimeout, err = getAnnotationDurationOr(ing, proxyResponseHeaderTimeout, defaults.ProxyResponseHeaderTimeout); err != nil {
+		return err
+	}
+	if transport.ReadTimeout, err = getAnnotationDurationOr(ing, proxyReadTimeout, defaults.ProxyReadTimeout); err != nil {
+		return err
+	}
+	if transport.WriteTimeout, err = getAnnotationDurationOr(ing, proxyWriteTimeout, defaults.ProxyWriteTimeout); err != nil {
+		return err
+	}
+
+	idleTimeout, err := getAnnotationDurationOr(ing, proxyKeepAliveIdleTimeout, defaults.ProxyKeepAliveIdleTimeout)
+	if err != nil {
+		return err
+	}
+	maxIdleConns := defaults.ProxyKeepAliveMaxIdleConns
+	if getAnnotation(ing, proxyKeepAliveMaxIdleConns) != "" {
+		if maxIdleConns, err = getAnnotationInt(ing, proxyKeepAliveMaxIdleConns); err != nil {
+			return err
+		}
+		if maxIdleConns < 0 {
+			return fmt.Errorf("invalid annotation %s %q, expected a positive number", proxyKeepAliveMaxIdleConns, getAnnotation(ing, proxyKeepAliveMaxIdleConns))
+		}
+	}
+	if idleTimeout != 0 || maxIdleConns != 0 {
+		transport.KeepAlive = &reverseproxy.KeepAlive{
+			IdleConnTimeout:     idleTimeout,
+			MaxIdleConnsPerHost: maxIdleConns,
+		}
+	}
+	return nil
+}
+
+// applyProxyBuffering sets the buffering and flush interval of the reverse proxy from the
+// annotations of an ingress, or from the ConfigMap defaults.
+func applyProxyBuffering(ing *v1.Ingress, defaults store.ConfigMapOptions, handler *reverseproxy.Handler) error {
+	var err error
+	if handler.RequestBuffers, err = getAnnotationValue(ing, proxyRequestBuffers, defaults.ProxyRequestBuffers, store.ParseBufferSize); err != nil {
+		return err
+	}
+	if handler.ResponseBuffers, err = getAnnotationValue(ing, proxyResponseBuffers, defaults.ProxyResponseBuffers, store.ParseBufferSize); err != nil {
+		return err
+	}
+	if handler.FlushInterval, err = getAnnotationValue(ing, proxyFlushInterval, defaults.ProxyFlushInterval, store.ParseFlushInterval); err != nil {
+		return err
+	}
+	return nil
+}
+
+// maxBodySize returns the max size of request bodies of an ingress, or 0 for no limit.
+func maxBodySize(ing *v1.Ingress, defaults store.ConfigMapOptions) (int64, error) {
+	return getAnnotationValue(ing, proxyMaxBodySize, defaults.ProxyMaxBodySize, store.ParseByteSize)
+}
+
+// getAnnotationDurationOr returns the duration of an annotation, or def if it is not set.
+func getAnnotationDurationOr(ing *v1.Ingress, rule string, def caddy.Duration) (caddy.Duration, error) {
+	if getAnnotation(ing, rule) == "" {
+		return def, nil
+	}
+	return getAnnotationDuration(ing, rule)
+}
+
+// getAnnotationValue parses the value of an annotation, or def if it is not set.
+func getAnnotationValue[T any](ing *v1.Ingress, rule, def string, parse func(string) (T, error)) (T, error) {
+	val := getAnnotation(ing, rule)
+	if val == "" {
+		val = def
+	}
+	v, err := parse(val)
+	if err != nil {
+		return v, fmt.Errorf("invalid annotation %s %q: %w", rule, val, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,165 @@
+package ingress
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+	"github.com/caddyserver/ingress/pkg/converter"
+	"github.com/caddyserver/ingress/pkg/store"
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestProxyOptionsConvertToCaddyConfig(t *testing.T) {
+	rpp := ReverseProxyPlugin{}
+
+	tests := []struct {
+		name               string
+		config             *store.ConfigMapOptions
+		annotations        map[string]string
+		expectedConfigPath string
+	}{
+		{
+			name: "annotations",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/proxy-dial-timeout":             "2s",
+				"caddy.ingress.kubernetes.io/proxy-response-header-timeout":  "30s",
+				"caddy.ingress.kubernetes.io/proxy-read-timeout":             "1m",
+				"caddy.ingress.kubernetes.io/proxy-write-timeout":            "1m",
+				"caddy.ingress.kubernetes.io/proxy-keepalive-idle-timeout":   "90s",
+				"caddy.ingress.kubernetes.io/proxy-keepalive-max-idle-conns": "64",
+				"caddy.ingress.kubernetes.io/proxy-request-buffers":          "unlimited",
+				"caddy.ingress.kubernetes.io/proxy-response-buffers":         "4MB",
+				"caddy.ingress.kubernetes.io/proxy-max-body-size":            "100MB",
+				"caddy.ingress.kubernetes.io/proxy-flush-interval":           "-1",
+			},
+			expectedConfigPath: "test_data/reverseproxy_proxy_options.json",
+		},
+		{
+			name: "configmap defaults overridden by annotations",
+			config: &store.ConfigMapOptions{
+				ProxyDialTimeout:           caddy.Duration(5e9),
+				ProxyReadTimeout:           caddy.Duration(10e9),
+				ProxyKeepAliveMaxIdleConns: 16,
+				ProxyMaxBodySize:           "10MB",
+				ProxyFlushInterval:         "100ms",
+			},
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/proxy-read-timeout":   "1h",
+				"caddy.ingress.kubernetes.io/proxy-flush-interval": "-1",
+			},
+			expectedConfigPath: "test_data/reverseproxy_proxy_defaults.json",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := store.NewStore(store.Options{}, "", &store.PodInfo{})
+			s.ConfigMap = test.config
+
+			input := converter.IngressMiddlewareInput{
+				Store: s,
+				Ingress: &networkingv1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: test.annotations,
+						Namespace:   "namespace",
+					},
+				},
+				Path: networkingv1.HTTPIngressPath{
+					Backend: networkingv1.IngressBackend{
+						Service: &networkingv1.IngressServiceBackend{
+							Name: "svcName",
+							Port: networkingv1.ServiceBackendPort{Number: 80},
+						},
+					},
+				},
+				Route: &caddyhttp.Route{},
+			}
+
+			route, err := rpp.IngressHandler(input)
+			require.NoError(t, err)
+
+			expectedCfg, err := os.ReadFile(test.expectedConfigPath)
+			require.NoError(t, err)
+
+			cfgJSON, err := json.Marshal(&route)
+			require.NoError(t, err)
+
+			require.JSONEq(t, string(expectedCfg), string(cfgJSON))
+		})
+	}
+}
+
+func TestMisconfiguredProxyOptions(t *testing.T) {
+	tests := []struct {
+		name          string
+		annotations   map[string]string
+		expectedError string
+	}{
+		{
+			name: "invalid timeout",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/proxy-read-timeout": "long",
+			},
+			expectedError: `invalid annotation proxy-read-timeout "long"`,
+		},
+		{
+			name: "negative max idle conns",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/proxy-keepalive-max-idle-conns": "-1",
+			},
+			expectedError: `invalid annotation proxy-keepalive-max-idle-conns "-1", expected a positive number`,
+		},
+		{
+			name: "invalid buffer size",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/proxy-response-buffers": "big",
+			},
+			expectedError: `invalid annotation proxy-response-buffers "big"`,
+		},
+		{
+			name: "unlimited max body size",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/proxy-max-body-size": "unlimited",
+			},
+			expectedError: `invalid annotation proxy-max-body-size "unlimited"`,
+		},
+		{
+			name: "invalid flush interval",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/proxy-flush-interval": "always",
+			},
+			expectedError: `invalid annotation proxy-flush-interval "always"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			input := converter.IngressMiddlewareInput{
+				Store: store.NewStore(store.Options{}, "", &store.PodInfo{}),
+				Ingress: &networkingv1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: test.annotations,
+						Namespace:   "namespace",
+					},
+				},
+				Path: networkingv1.HTTPIngressPath{
+					Backend: networkingv1.IngressBackend{
+						Service: &networkingv1.IngressServiceBackend{
+							Name: "svcName",
+							Port: networkingv1.ServiceBackendPort{Number: 80},
+						},
+					},
+				},
+				Route: &caddyhttp.Route{},
+			}
+
+			_, err := ReverseProxyPlugin{}.IngressHandler(input)
+			require.ErrorContains(t, err, test.expectedError)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package ingress
+
+import (
+	"fmt"
+
+	"github.com/caddyserver/caddy/v2/caddyconfig"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+	"github.com/caddyserver/ingress/internal/controller"
+	"github.com/caddyserver/ingress/internal/resource"
+	"github.com/caddyserver/ingress/pkg/converter"
+	"github.com/caddyserver/ingress/pkg/store"
+)
+
+const defaultResourceIndex = "index.html"
+
+type ResourcePlugin struct{}
+
+func (p ResourcePlugin) IngressPlugin() converter.PluginInfo {
+	return converter.PluginInfo{
+		Name: "ingress.resource",
+		// Should always go last by default, like the reverse proxy
+		Priority: -10,
+		New:      func() converter.Plugin { return new(ResourcePlugin) },
+	}
+}
+
+// IngressHandler serves the keys of the ConfigMap of a Resource backend as files.
+func (p ResourcePlugin) IngressHandler(input converter.IngressMiddlewareInput) (*caddyhttp.Route, error) {
+	res := input.Path.Backend.Resource
+	if res == nil {
+		return input.Route, nil
+	}
+
+	name, ok := store.ResourceConfigMapName(input.Path.Backend)
+	if !ok {
+		apiGroup := ""
+		if res.APIGroup != nil {
+			apiGroup = *res.APIGroup
+		}
+		return nil, fmt.Errorf("unsupported resource backend %s %q, only ConfigMap is supported", res.Kind, apiGroup)
+	}
+	if input.Store.GetResourceConfigMap(input.Ingress.Namespace, name) == nil {
+		return nil, fmt.Errorf("configmap %s not found", name)
+	}
+
+	root := controller.ResourceDir(input.Ingress.Namespace, name)
+	index := getAnnotation(input.Ingress, resourceIndex)
+	if index == "" {
+		index = defaultResourceIndex
+	}
+
+	input.Route.HandlersRaw = append(input.Route.HandlersRaw,
+		caddyconfig.JSONModuleObject(resource.Handler{
+			Root:        root,
+			Index:       index,
+			SPAFallback: getAnnotationBool(input.Ingress, resourceSPAFallback, false),
+		}, "handler", "ingress_resource", nil),
+	)
+	return input.Route, nil
+}
+
+func init() {
+	converter.RegisterPlugin(ResourcePlugin{})
+}
+
+// Interface guards
+var (
+	_ = converter.IngressMiddleware(ResourcePlugin{})
+)
@@ -0,0 +1,94 @@
+package ingress
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+	"github.com/caddyserver/ingress/pkg/converter"
+	"github.com/caddyserver/ingress/pkg/store"
+	"github.com/stretchr/testify/require"
+	apiv1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestResourceConvertToCaddyConfig(t *testing.T) {
+	// resources are served from the default folder
+	t.Setenv("RUNTIME_DIRECTORY", "")
+	rp := ResourcePlugin{}
+
+	s := store.NewStore(store.Options{}, "", &store.PodInfo{})
+	s.AddResourceConfigMap(&apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "pages", Namespace: "namespace", UID: "pages"},
+		Data:       map[string]string{"index.html": "<h1>Down for maintenance</h1>"},
+	})
+
+	tests := []struct {
+		name               string
+		annotations        map[string]string
+		resource           *apiv1.TypedLocalObjectReference
+		expectedConfigPath string
+		expectedError      string
+	}{
+		{
+			name:               "configmap",
+			resource:           &apiv1.TypedLocalObjectReference{Kind: "ConfigMap", Name: "pages"},
+			expectedConfigPath: "test_data/resource.json",
+		},
+		{
+			name: "configmap with index and SPA fallback",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/resource-index":        "app.html",
+				"caddy.ingress.kubernetes.io/resource-spa-fallback": "true",
+			},
+			resource:           &apiv1.TypedLocalObjectReference{Kind: "ConfigMap", Name: "pages"},
+			expectedConfigPath: "test_data/resource_spa.json",
+		},
+		{
+			name:          "missing configmap",
+			resource:      &apiv1.TypedLocalObjectReference{Kind: "ConfigMap", Name: "missing"},
+			expectedError: "configmap missing not found",
+		},
+		{
+			name:          "unsupported resource",
+			resource:      &apiv1.TypedLocalObjectReference{APIGroup: ptr.To("example.com"), Kind: "Bucket", Name: "pages"},
+			expectedError: `unsupported resource backend Bucket "example.com", only ConfigMap is supported`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			input := converter.IngressMiddlewareInput{
+				Store: s,
+				Ingress: &networkingv1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: test.annotations,
+						Namespace:   "namespace",
+					},
+				},
+				Path: networkingv1.HTTPIngressPath{
+					Backend: networkingv1.IngressBackend{Resource: test.resource},
+				},
+				Route: &caddyhttp.Route{},
+			}
+
+			route, err := rp.IngressHandler(input)
+			if test.expectedError != "" {
+				require.EqualError(t, err, test.expectedError)
+				return
+			}
+			require.NoError(t, err)
+
+			expectedCfg, err := os.ReadFile(test.expectedConfigPath)
+			require.NoError(t, err)
+
+			cfgJSON, err := json.Marshal(&route)
+			require.NoError(t, err)
+
+			require.JSONEq(t, string(expectedCfg), string(cfgJSON))
+		})
+	}
+}
@@ -2,11 +2,14 @@ package ingress
 
 import (
 	"fmt"
+	"net/http"
 	"net/netip"
 	"strings"
 
 	"github.com/caddyserver/caddy/v2/caddyconfig"
 	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp/headers"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp/requestbody"
 	"github.com/caddyserver/caddy/v2/modules/caddyhttp/reverseproxy"
 	"github.com/caddyserver/ingress/pkg/converter"
 )
@@ -29,21 +32,38 @@ func (p ReverseProxyPlugin) IngressHandler(input converter.IngressMiddlewareInpu
 	backendProtocol := strings.ToLower(getAnnotation(ing, backendProtocol))
 	trustedProxiesAnnotation := strings.ToLower(getAnnotation(ing, trustedProxies))
 
-	// TODO :-
-	// when setting the upstream url we should bypass kube-dns and get the ip address of
-	// the pod for the deployment we are proxying to so that we can proxy to that ip address port.
-	// this is good for session affinity and increases performance.
-	clusterHostName := fmt.Sprintf("%v.%v.svc.cluster.local:%d", path.Backend.Service.Name, ing.Namespace, path.Backend.Service.Port.Number)
+	// resource backends are served by the resource plugin
+	if path.Backend.Service == nil {
+		return input.Route, nil
+	}
+
+	port, err := servicePort(input.Store, ing.Namespace, path.Backend.Service)
+	if err != nil {
+		return nil, err
+	}
+
+	upstreams, err := serviceUpstreams(input.Store, ing, path.Backend.Service, port)
+	if err != nil {
+		return nil, err
+	}
+	externalName := serviceExternalName(input.Store, ing.Namespace, path.Backend.Service.Name)
+
+	defaults := proxyDefaults(input.Store)
 
 	transport := &reverseproxy.HTTPTransport{}
+	if err := applyProxyTimeouts(ing, defaults, transport); err != nil {
+		return nil, err
+	}
 
 	if backendProtocol == "https" {
 		transport.TLS = &reverseproxy.TLSConfig{
 			InsecureSkipVerify: getAnnotationBool(ing, insecureSkipVerify, true),
 		}
+		if externalName != "" && getAnnotationBool(ing, externalNameSNI, true) {
+			transport.TLS.ServerName = externalName
+		}
 	}
 
-	var err error
 	var parsedProxies []string
 	if trustedProxiesAnnotation != "" {
 		trustedProxies := strings.Split(trustedProxiesAnnotation, ",")
@@ -53,12 +73,48 @@ func (p ReverseProxyPlugin) IngressHandler(input converter.IngressMiddlewareInpu
 		}
 	}
 
+	lb, err := loadBalancing(ing)
+	if err != nil {
+		return nil, err
+	}
+
+	hc, err := healthChecks(ing)
+	if err != nil {
+		return nil, err
+	}
+
 	handler := reverseproxy.Handler{
-		TransportRaw: caddyconfig.JSONModuleObject(transport, "protocol", "http", nil),
-		Upstreams: reverseproxy.UpstreamPool{
-			{Dial: clusterHostName},
-		},
+		TransportRaw:   caddyconfig.JSONModuleObject(transport, "protocol", "http", nil),
+		Upstreams:      upstreams,
 		TrustedProxies: parsedProxies,
+		LoadBalancing:  lb,
+		HealthChecks:   hc,
+	}
+	if err := applyProxyBuffering(ing, defaults, &handler); err != nil {
+		return nil, err
+	}
+
+	// external targets usually serve their own hostname, not the one of the ingress
+	if externalName != "" && getAnnotationBool(ing, externalNameHostHeader, true) {
+		handler.Headers = &headers.Handler{
+			Request: &headers.HeaderOps{
+				Set: http.Header{"Host": []string{externalName}},
+			},
+		}
+	}
+
+	// request bodies are limited before they are proxied
+	maxSize, err := maxBodySize(ing, defaults)
+	if err != nil {
+		return nil, err
+	}
+	if maxSize > 0 {
+		input.Route.HandlersRaw = append(input.Route.HandlersRaw, caddyconfig.JSONModuleObject(
+			requestbody.RequestBody{MaxSize: maxSize},
+			"handler",
+			"request_body",
+			nil,
+		))
 	}
 
 	handlerModule := caddyconfig.JSONModuleObject(
@@ -7,7 +7,9 @@ import (
 
 	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
 	"github.com/caddyserver/ingress/pkg/converter"
+	"github.com/caddyserver/ingress/pkg/store"
 	"github.com/stretchr/testify/require"
+	apiv1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -53,6 +55,7 @@ func TestTrustedProxesConvertToCaddyConfig(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			input := converter.IngressMiddlewareInput{
+				Store: store.NewStore(store.Options{}, "", &store.PodInfo{}),
 				Ingress: &networkingv1.Ingress{
 					ObjectMeta: metav1.ObjectMeta{
 						Annotations: test.annotations,
@@ -84,6 +87,73 @@ func TestTrustedProxesConvertToCaddyConfig(t *testing.T) {
 	}
 }
 
+func TestExternalNameConvertToCaddyConfig(t *testing.T) {
+	rpp := ReverseProxyPlugin{}
+
+	s := store.NewStore(store.Options{}, "", &store.PodInfo{})
+	s.AddService(&apiv1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "svcName", Namespace: "namespace", UID: "svcName"},
+		Spec:       apiv1.ServiceSpec{Type: apiv1.ServiceTypeExternalName, ExternalName: "api.example.com"},
+	})
+
+	tests := []struct {
+		name               string
+		annotations        map[string]string
+		expectedConfigPath string
+	}{
+		{
+			name: "external name",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/backend-protocol": "https",
+			},
+			expectedConfigPath: "test_data/reverseproxy_external_name.json",
+		},
+		{
+			name: "external name keeping host and sni",
+			annotations: map[string]string{
+				"caddy.ingress.kubernetes.io/backend-protocol":          "https",
+				"caddy.ingress.kubernetes.io/external-name-host-header": "false",
+				"caddy.ingress.kubernetes.io/external-name-sni":         "false",
+			},
+			expectedConfigPath: "test_data/reverseproxy_external_name_keep_host.json",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			input := converter.IngressMiddlewareInput{
+				Store: s,
+				Ingress: &networkingv1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: test.annotations,
+						Namespace:   "namespace",
+					},
+				},
+				Path: networkingv1.HTTPIngressPath{
+					Backend: networkingv1.IngressBackend{
+						Service: &networkingv1.IngressServiceBackend{
+							Name: "svcName",
+							Port: networkingv1.ServiceBackendPort{Number: 443},
+						},
+					},
+				},
+				Route: &caddyhttp.Route{},
+			}
+
+			route, err := rpp.IngressHandler(input)
+			require.NoError(t, err)
+
+			expectedCfg, err := os.ReadFile(test.expectedConfigPath)
+			require.NoError(t, err)
+
+			cfgJSON, err := json.Marshal(&route)
+			require.NoError(t, err)
+
+			require.JSONEq(t, string(expectedCfg), string(cfgJSON))
+		})
+	}
+}
+
 func TestMisconfiguredTrustedProxiesConvertToCaddyConfig(t *testing.T) {
 	rpp := ReverseProxyPlugin{}
 
@@ -139,6 +209,7 @@ func TestMisconfiguredTrustedProxiesConvertToCaddyConfig(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			input := converter.IngressMiddlewareInput{
+				Store: store.NewStore(store.Options{}, "", &store.PodInfo{}),
 				Ingress: &networkingv1.Ingress{
 					ObjectMeta: metav1.ObjectMeta{
 						Annotations: test.annotations,
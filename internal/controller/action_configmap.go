@@ -44,28 +44,63 @@ func (c *CaddyController) onConfigMapDeleted(obj *v1.ConfigMap) {
 }
 
 func (r ConfigMapAddedAction) handle(c *CaddyController) error {
-	c.logger.Infof("ConfigMap created (%s/%s)", r.resource.Namespace, r.resource.Name)
-
-	cfg, err := store.ParseConfigMap(r.resource)
-	if err == nil {
-		c.resourceStore.ConfigMap = cfg
+	cm, ok := latestResource(c.informers.ConfigMap, r.resource)
+	if !ok {
+		c.logger.Debugf("skipping deleted ConfigMap (%s/%s)", r.resource.Namespace, r.resource.Name)
+		return nil
 	}
-	return err
+
+	c.logger.Infof("ConfigMap created (%s/%s)", cm.Namespace, cm.Name)
+
+	return c.applyConfigMap(cm)
 }
 
 func (r ConfigMapUpdatedAction) handle(c *CaddyController) error {
-	c.logger.Infof("ConfigMap updated (%s/%s)", r.resource.Namespace, r.resource.Name)
+	cm, ok := latestResource(c.informers.ConfigMap, r.resource)
+	if !ok {
+		c.logger.Debugf("skipping deleted ConfigMap (%s/%s)", r.resource.Namespace, r.resource.Name)
+		return nil
+	}
+
+	c.logger.Infof("ConfigMap updated (%s/%s)", cm.Namespace, cm.Name)
+
+	return c.applyConfigMap(cm)
+}
 
-	cfg, err := store.ParseConfigMap(r.resource)
-	if err == nil {
-		c.resourceStore.ConfigMap = cfg
+// applyConfigMap replaces the global options with the ones of cm. Retrying does not fix an
+// invalid ConfigMap, the previous options are kept until it is updated.
+func (c *CaddyController) applyConfigMap(cm *v1.ConfigMap) error {
+	cfg, err := store.ParseConfigMap(cm)
+	if err != nil {
+		c.logger.Errorf("invalid ConfigMap (%s/%s), keeping the previous global options: %v", cm.Namespace, cm.Name, err)
+		c.events.Warning(cm, reasonInvalidConfigMap, err.Error())
+		return nil
 	}
-	return err
+
+	c.resourceStore.ConfigMap = cfg
+	c.applyIngressSelector()
+	return c.syncBackends()
 }
 
 func (r ConfigMapDeletedAction) handle(c *CaddyController) error {
 	c.logger.Infof("ConfigMap deleted (%s/%s)", r.resource.Namespace, r.resource.Name)
 
 	c.resourceStore.ConfigMap = nil
-	return nil
+	c.applyIngressSelector()
+	return c.syncBackends()
+}
+
+// cachedConfigMapOptions returns the global options from the informer cache, or nil
+// if the ConfigMap does not exist or is invalid.
+func (c *CaddyController) cachedConfigMapOptions() *store.ConfigMapOptions {
+	obj, exists, err := c.informers.ConfigMap.GetIndexer().GetByKey(c.resourceStore.ConfigNamespace + "/" + c.configMapName)
+	if err != nil || !exists {
+		return nil
+	}
+
+	cfg, err := store.ParseConfigMap(obj.(*v1.ConfigMap))
+	if err != nil {
+		return nil
+	}
+	return cfg
 }
@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/caddyserver/ingress/pkg/store"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestInvalidConfigMapKeepsPreviousOptions(t *testing.T) {
+	factory := informers.NewSharedInformerFactory(fake.NewClientset(), 0)
+	cmInformer := factory.Core().V1().ConfigMaps().Informer()
+	cm := &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "caddy-global-options", Namespace: "caddy-system"},
+		Data:       map[string]string{"upstreamAddressing": "invalid"},
+	}
+	require.NoError(t, cmInformer.GetIndexer().Add(cm))
+
+	events, fakeRecorder, _ := newFakeEventRecorder(time.Now())
+	previous := &store.ConfigMapOptions{Debug: true}
+	c := &CaddyController{
+		logger:        zap.NewNop().Sugar(),
+		events:        events,
+		informers:     &Informer{ConfigMap: cmInformer},
+		resourceStore: &store.Store{ConfigMap: previous},
+	}
+
+	for _, action := range []Action{
+		ConfigMapAddedAction{resource: cm},
+		ConfigMapUpdatedAction{resource: cm, oldResource: cm},
+	} {
+		// the action is not retried, the previous options are kept
+		require.NoError(t, action.handle(c))
+		require.Same(t, previous, c.resourceStore.ConfigMap)
+	}
+
+	recorded := recordedEvents(fakeRecorder)
+	require.Len(t, recorded, 1)
+	require.Contains(t, recorded[0], "Warning InvalidConfigMap invalid upstreamAddressing")
+}
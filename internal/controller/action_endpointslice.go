@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/caddyserver/ingress/internal/k8s"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// EndpointSliceAddedAction provides an implementation of the action interface.
+type EndpointSliceAddedAction struct {
+	resource *discoveryv1.EndpointSlice
+}
+
+// EndpointSliceUpdatedAction provides an implementation of the action interface.
+type EndpointSliceUpdatedAction struct {
+	resource    *discoveryv1.EndpointSlice
+	oldResource *discoveryv1.EndpointSlice
+}
+
+// EndpointSliceDeletedAction provides an implementation of the action interface.
+type EndpointSliceDeletedAction struct {
+	resource *discoveryv1.EndpointSlice
+}
+
+// onEndpointSliceAdded runs when an endpoint slice is added to the cluster.
+func (c *CaddyController) onEndpointSliceAdded(obj *discoveryv1.EndpointSlice) {
+	c.syncQueue.Add(EndpointSliceAddedAction{
+		resource: obj,
+	})
+}
+
+// onEndpointSliceUpdated is run when an endpoint slice is updated in the cluster.
+func (c *CaddyController) onEndpointSliceUpdated(old *discoveryv1.EndpointSlice, new *discoveryv1.EndpointSlice) {
+	// periodic resyncs do not change endpoints
+	if old.ResourceVersion == new.ResourceVersion {
+		return
+	}
+	c.syncQueue.Add(EndpointSliceUpdatedAction{
+		resource:    new,
+		oldResource: old,
+	})
+}
+
+// onEndpointSliceDeleted is run when an endpoint slice is deleted from the cluster.
+func (c *CaddyController) onEndpointSliceDeleted(obj *discoveryv1.EndpointSlice) {
+	c.syncQueue.Add(EndpointSliceDeletedAction{
+		resource: obj,
+	})
+}
+
+func (r EndpointSliceAddedAction) handle(c *CaddyController) error {
+	return c.syncEndpointSlice(r.resource)
+}
+
+func (r EndpointSliceUpdatedAction) handle(c *CaddyController) error {
+	return c.syncEndpointSlice(r.resource)
+}
+
+func (r EndpointSliceDeletedAction) handle(c *CaddyController) error {
+	c.resourceStore.PluckEndpointSlice(r.resource)
+	return nil
+}
+
+// syncEndpointSlice adds the endpoint slice to the store if its service is referenced
+// by an ingress routing to pods.
+func (c *CaddyController) syncEndpointSlice(slice *discoveryv1.EndpointSlice) error {
+	slice, ok := latestResource(c.endpointSliceInformer(slice.Namespace), slice)
+	if !ok || !c.resourceStore.IsPodRoutedService(slice.Namespace, slice.Labels[discoveryv1.LabelServiceName]) {
+		c.resourceStore.PluckEndpointSlice(slice)
+		return nil
+	}
+
+	c.logger.Debugf("EndpointSlice changed (%s/%s)", slice.Namespace, slice.Name)
+	c.resourceStore.AddEndpointSlice(slice)
+	return nil
+}
+
+// syncPodRouting starts listening to endpoint slices if at least one ingress routes to pods,
+// and syncs the store with the endpoint slices of the services they reference.
+func (c *CaddyController) syncPodRouting() error {
+	if !c.watchingEndpointSlices && c.resourceStore.HasPodRouting() {
+		c.watchingEndpointSlices = true
+		for _, ns := range c.namespaces {
+			if err := c.watchNamespaceEndpointSlices(ns); err != nil {
+				return err
+			}
+		}
+	}
+
+	slices := []*discoveryv1.EndpointSlice{}
+	for _, ns := range c.namespaces {
+		if ns.EndpointSlice == nil {
+			continue
+		}
+		for _, obj := range ns.EndpointSlice.GetStore().List() {
+			slice := obj.(*discoveryv1.EndpointSlice)
+			if c.resourceStore.IsPodRoutedService(slice.Namespace, slice.Labels[discoveryv1.LabelServiceName]) {
+				slices = append(slices, slice)
+			}
+		}
+	}
+	c.resourceStore.EndpointSlices = slices
+	return nil
+}
+
+// watchNamespaceEndpointSlices starts listening to the endpoint slices of a watched namespace.
+func (c *CaddyController) watchNamespaceEndpointSlices(ns *namespaceInformers) error {
+	if ns.EndpointSlice != nil {
+		return nil
+	}
+
+	ns.EndpointSlice = k8s.WatchEndpointSlices(k8s.EndpointSliceParams{
+		InformerFactory: ns.factory,
+	}, k8s.EndpointSliceHandlers{
+		AddFunc:    c.onEndpointSliceAdded,
+		UpdateFunc: c.onEndpointSliceUpdated,
+		DeleteFunc: c.onEndpointSliceDeleted,
+	})
+
+	ns.factory.Start(ns.ctx.Done())
+	if !cache.WaitForCacheSync(ns.ctx.Done(), ns.EndpointSlice.HasSynced) {
+		return fmt.Errorf("timed out waiting for endpoint slices cache to sync")
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// HistoryUpdatedAction provides an implementation of the action interface.
+type HistoryUpdatedAction struct {
+	resource *v1.Secret
+}
+
+// onHistoryChanged runs when the config history secret is created or updated.
+func (c *CaddyController) onHistoryChanged(obj *v1.Secret) {
+	c.syncQueue.Add(HistoryUpdatedAction{
+		resource: obj,
+	})
+}
+
+func (r HistoryUpdatedAction) handle(c *CaddyController) error {
+	// The rollback target is resolved against the history the annotation was set on,
+	// not against a newer version already written by the leader.
+	target := r.resource.Annotations[rollbackAnnotation]
+	if target != c.handledRollback {
+		c.handledRollback = target
+		if target != "" {
+			history := newConfigHistory(c.history.size)
+			if err := history.ReadSecret(r.resource); err != nil {
+				c.events.Warning(r.resource, reasonRollbackFailed, err.Error())
+				return nil
+			}
+			if err := c.rollback(history, target); err != nil {
+				c.events.Warning(r.resource, reasonRollbackFailed, err.Error())
+				c.logger.Errorf("could not roll back caddy config: %v", err)
+			}
+			return nil
+		}
+	}
+
+	// The leader owns the history, other replicas keep a copy to be able to take over.
+	if !c.IsLeader() {
+		if err := c.history.ReadSecret(r.resource); err != nil {
+			c.logger.Warnf("could not read config history: %v", err)
+		}
+	}
+	return nil
+}
+
+// PersistHistoryAction provides an implementation of the action interface.
+// It is queued when the leader lease is acquired, the history is only persisted by the leader.
+type PersistHistoryAction struct{}
+
+func (r PersistHistoryAction) handle(c *CaddyController) error {
+	c.persistHistory()
+	return nil
+}
+
+// rollback loads a revision from history and pins it until the generated config changes.
+func (c *CaddyController) rollback(history *configHistory, target string) error {
+	rev, err := history.Resolve(target)
+	if err != nil {
+		return err
+	}
+
+	c.logger.Infof("rolling back caddy config to revision %d", rev.Revision)
+	if !bytes.Equal(c.lastAppliedConfig, rev.config) {
+		if err := loadConfig(rev.config, false); err != nil {
+			return fmt.Errorf("caddy rejected revision %d: %v", rev.Revision, err)
+		}
+		c.setAppliedConfig(rev.config)
+	}
+
+	generated, _, err := c.generateConfig()
+	if err != nil {
+		return err
+	}
+	c.rollbackPin = generated
+
+	c.history.Add(rev.config, fmt.Sprintf("rollback to revision %d", rev.Revision))
+	c.persistHistory()
+	return nil
+}
+
+// loadHistory restores the config history from its Secret.
+func (c *CaddyController) loadHistory() {
+	if c.history.size == 0 {
+		return
+	}
+
+	key := c.resourceStore.ConfigNamespace + "/" + c.historyName
+	obj, exists, err := c.informers.History.GetIndexer().GetByKey(key)
+	if err != nil || !exists {
+		return
+	}
+
+	secret := obj.(*v1.Secret)
+	// a rollback requested before startup is stale
+	c.handledRollback = secret.Annotations[rollbackAnnotation]
+	if err := c.history.ReadSecret(secret); err != nil {
+		c.logger.Warnf("could not read config history: %v", err)
+	}
+}
+
+// restoreLastKnownGood loads the last config from history when the generated one could not be loaded.
+func (c *CaddyController) restoreLastKnownGood() {
+	rev := c.history.Current()
+	if rev == nil {
+		return
+	}
+
+	c.logger.Warnf("restoring last known good caddy config, revision %d", rev.Revision)
+	if err := loadConfig(rev.config, false); err != nil {
+		c.logger.Errorf("could not restore last known good caddy config: %v", err)
+		return
+	}
+	c.setAppliedConfig(rev.config)
+}
+
+// persistHistory writes the config history in its Secret, only the leader does it.
+func (c *CaddyController) persistHistory() {
+	if c.history.size == 0 || !c.IsLeader() {
+		return
+	}
+
+	ctx := context.TODO()
+	client := c.kubeClient.CoreV1().Secrets(c.resourceStore.ConfigNamespace)
+
+	secret, err := client.Get(ctx, c.historyName, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		secret = &v1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:      c.historyName,
+			Namespace: c.resourceStore.ConfigNamespace,
+		}}
+		if err = c.history.WriteSecret(secret); err == nil {
+			_, err = client.Create(ctx, secret, metav1.CreateOptions{})
+		}
+	} else if err == nil {
+		// the rollback was handled, allow requesting the same one again
+		if secret.Annotations[rollbackAnnotation] == c.handledRollback {
+			delete(secret.Annotations, rollbackAnnotation)
+		}
+		if err = c.history.WriteSecret(secret); err == nil {
+			_, err = client.Update(ctx, secret, metav1.UpdateOptions{})
+		}
+	}
+
+	if err != nil {
+		c.logger.Warnf("could not persist config history: %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+	"github.com/caddyserver/ingress/pkg/converter"
+	"github.com/caddyserver/ingress/pkg/store"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testHistoryName = "caddy-ingress-controller-history"
+
+// newHistoryTestController returns a leader controller generating a config that serves the
+// routes of conv.
+func newHistoryTestController(client *fake.Clientset, conv *fakeConverter) *CaddyController {
+	events, _, _ := newFakeEventRecorder(time.Now())
+	c := &CaddyController{
+		logger:        zap.NewNop().Sugar(),
+		kubeClient:    client,
+		events:        events,
+		converter:     conv,
+		maxRetries:    2,
+		history:       newConfigHistory(5),
+		historyName:   testHistoryName,
+		informers:     &Informer{},
+		resourceStore: store.NewStore(store.Options{}, "caddy-system", nil),
+	}
+	c.isLeader.Store(true)
+	return c
+}
+
+// hostRoutes returns the routes of a single ingress serving host.
+func hostRoutes(host string) converter.IngressRoutes {
+	return converter.IngressRoutes{types.UID("ing"): caddyhttp.RouteList{{Group: host}}}
+}
+
+// getHistorySecret returns the persisted history.
+func getHistorySecret(t *testing.T, client *fake.Clientset) *apiv1.Secret {
+	secret, err := client.CoreV1().Secrets("caddy-system").Get(context.Background(), testHistoryName, metav1.GetOptions{})
+	require.NoError(t, err)
+	return secret
+}
+
+func TestRollbackThroughAnnotation(t *testing.T) {
+	client := fake.NewClientset()
+	conv := &fakeConverter{}
+	c := newHistoryTestController(client, conv)
+	loaded := stubLoadConfig(t, func([]byte) error { return nil })
+
+	conv.routes = hostRoutes("v1.example.com")
+	require.NoError(t, c.reloadCaddy(nil))
+	v1 := c.lastAppliedConfig
+	conv.routes = hostRoutes("v2.example.com")
+	require.NoError(t, c.reloadCaddy(nil))
+	require.Len(t, *loaded, 2)
+
+	// request a rollback to the previous revision on the history secret
+	secret := getHistorySecret(t, client)
+	secret.Annotations = map[string]string{rollbackAnnotation: rollbackPrevious}
+	require.NoError(t, HistoryUpdatedAction{resource: secret}.handle(c))
+
+	require.Len(t, *loaded, 3)
+	require.Equal(t, v1, (*loaded)[2])
+	require.Equal(t, v1, c.lastAppliedConfig)
+	require.Equal(t, "rollback to revision 1", c.history.Current().Trigger)
+
+	// the rollback is persisted, and the annotation removed so that it can be requested again
+	persisted := newConfigHistory(5)
+	secret = getHistorySecret(t, client)
+	require.NoError(t, persisted.ReadSecret(secret))
+	require.Equal(t, 3, persisted.Current().Revision)
+	require.NotContains(t, secret.Annotations, rollbackAnnotation)
+
+	// the same rollback request is only handled once
+	secret.Annotations = map[string]string{rollbackAnnotation: rollbackPrevious}
+	require.NoError(t, HistoryUpdatedAction{resource: secret}.handle(c))
+	require.Len(t, *loaded, 3)
+}
+
+func TestRollbackFailureIsReported(t *testing.T) {
+	client := fake.NewClientset()
+	conv := &fakeConverter{routes: hostRoutes("v1.example.com")}
+	c := newHistoryTestController(client, conv)
+	events, fakeRecorder, _ := newFakeEventRecorder(time.Now())
+	c.events = events
+	stubLoadConfig(t, func([]byte) error { return nil })
+	require.NoError(t, c.reloadCaddy(nil))
+
+	secret := getHistorySecret(t, client)
+	secret.Annotations = map[string]string{rollbackAnnotation: "42"}
+	require.NoError(t, HistoryUpdatedAction{resource: secret}.handle(c))
+	require.Equal(t, []string{"Warning RollbackFailed revision 42 is not in history"}, recordedEvents(fakeRecorder))
+	require.Equal(t, 1, c.history.Current().Revision)
+}
+
+func TestRollbackPinsRevision(t *testing.T) {
+	client := fake.NewClientset()
+	conv := &fakeConverter{}
+	c := newHistoryTestController(client, conv)
+	loaded := stubLoadConfig(t, func([]byte) error { return nil })
+
+	conv.routes = hostRoutes("v1.example.com")
+	require.NoError(t, c.reloadCaddy(nil))
+	v1 := c.lastAppliedConfig
+	conv.routes = hostRoutes("v2.example.com")
+	require.NoError(t, c.reloadCaddy(nil))
+
+	require.NoError(t, c.rollback(c.history, "1"))
+	require.Len(t, *loaded, 3)
+
+	// the generated config did not change, the rolled back revision is kept
+	require.NoError(t, c.reloadCaddy(nil))
+	require.Len(t, *loaded, 3)
+	require.Equal(t, v1, c.lastAppliedConfig)
+
+	// a change in the cluster releases the pin
+	conv.routes = hostRoutes("v3.example.com")
+	require.NoError(t, c.reloadCaddy(nil))
+	require.Len(t, *loaded, 4)
+	require.NotEqual(t, v1, c.lastAppliedConfig)
+	require.Nil(t, c.rollbackPin)
+}
+
+func TestRejectedConfigIsNotRetried(t *testing.T) {
+	client := fake.NewClientset()
+	conv := &fakeConverter{routes: hostRoutes("good.example.com")}
+	c := newHistoryTestController(client, conv)
+
+	var attempts int
+	rejected := errors.New("rejected")
+	stubLoadConfig(t, func([]byte) error {
+		attempts++
+		if conv.routes[types.UID("ing")][0].Group == "bad.example.com" {
+			return rejected
+		}
+		return nil
+	})
+	require.NoError(t, c.reloadCaddy(nil))
+	good := c.lastAppliedConfig
+
+	// the bad config is tried maxRetries+1 times, then skipped
+	conv.routes = hostRoutes("bad.example.com")
+	for range c.maxRetries + 1 {
+		require.Error(t, c.reloadCaddy(nil))
+	}
+	require.Equal(t, c.maxRetries+2, attempts)
+	require.NoError(t, c.reloadCaddy(nil))
+	require.Equal(t, c.maxRetries+2, attempts)
+	require.Equal(t, good, c.lastAppliedConfig)
+}
+
+func TestHistoryPersistAndRestore(t *testing.T) {
+	client := fake.NewClientset()
+	conv := &fakeConverter{routes: hostRoutes("v1.example.com")}
+	c := newHistoryTestController(client, conv)
+	stubLoadConfig(t, func([]byte) error { return nil })
+	require.NoError(t, c.reloadCaddy(nil))
+	applied := c.lastAppliedConfig
+
+	// followers do not persist the history
+	follower := newHistoryTestController(client, conv)
+	follower.isLeader.Store(false)
+	follower.history.Add([]byte(`{"follower":true}`), "initial sync")
+	follower.persistHistory()
+	persisted := newConfigHistory(5)
+	require.NoError(t, persisted.ReadSecret(getHistorySecret(t, client)))
+	require.Equal(t, configHash(applied), persisted.Current().Hash)
+
+	// a restarted controller restores the last known good config when the generated one is rejected
+	factory := informers.NewSharedInformerFactory(client, 0)
+	historyInformer := factory.Core().V1().Secrets().Informer()
+	require.NoError(t, historyInformer.GetIndexer().Add(getHistorySecret(t, client)))
+
+	restarted := newHistoryTestController(client, &fakeConverter{routes: hostRoutes("bad.example.com")})
+	restarted.informers.History = historyInformer
+	loaded := stubLoadConfig(t, func(config []byte) error {
+		if string(config) != string(applied) {
+			return errors.New("rejected")
+		}
+		return nil
+	})
+
+	restarted.loadHistory()
+	require.Error(t, restarted.reloadCaddy(nil))
+	restarted.restoreLastKnownGood()
+	require.Equal(t, [][]byte{applied}, *loaded)
+	require.Equal(t, applied, restarted.lastAppliedConfig)
+}
@@ -43,22 +43,53 @@ func (c *CaddyController) onIngressDeleted(obj *v1.Ingress) {
 }
 
 func (r IngressAddedAction) handle(c *CaddyController) error {
-	c.logger.Infof("Ingress created (%s/%s)", r.resource.Namespace, r.resource.Name)
-	// add this ingress to the internal store
-	c.resourceStore.AddIngress(r.resource)
+	ing, ok := latestResource(c.ingressInformer(r.resource.Namespace), r.resource)
+	if !ok {
+		c.logger.Debugf("skipping creation of deleted Ingress (%s/%s)", r.resource.Namespace, r.resource.Name)
+		return nil
+	}
+
+	// add this ingress to the internal store if it is controlled by us
+	ing, ok = c.syncIngress(ing)
+	if !ok {
+		c.logger.Debugf("ignoring Ingress of another class (%s/%s)", r.resource.Namespace, r.resource.Name)
+		return nil
+	}
+	c.logger.Infof("Ingress created (%s/%s)", ing.Namespace, ing.Name)
 
 	// Ingress may now have a TLS config
-	return c.watchTLSSecrets()
+	if err := c.watchTLSSecrets(); err != nil {
+		return err
+	}
+	c.checkTLSSecrets(ing)
+
+	// Ingress may now reference other services
+	return c.syncBackends()
 }
 
 func (r IngressUpdatedAction) handle(c *CaddyController) error {
-	c.logger.Infof("Ingress updated (%s/%s)", r.resource.Namespace, r.resource.Name)
+	ing, ok := latestResource(c.ingressInformer(r.resource.Namespace), r.resource)
+	if !ok {
+		c.logger.Debugf("skipping update of deleted Ingress (%s/%s)", r.resource.Namespace, r.resource.Name)
+		return nil
+	}
 
-	// add or update this ingress in the internal store
-	c.resourceStore.AddIngress(r.resource)
+	// add or update this ingress in the internal store, or remove it if its class changed
+	ing, ok = c.syncIngress(ing)
+	if !ok {
+		c.logger.Debugf("ignoring Ingress of another class (%s/%s)", r.resource.Namespace, r.resource.Name)
+		return nil
+	}
+	c.logger.Infof("Ingress updated (%s/%s)", ing.Namespace, ing.Name)
 
 	// Ingress may now have a TLS config
-	return c.watchTLSSecrets()
+	if err := c.watchTLSSecrets(); err != nil {
+		return err
+	}
+	c.checkTLSSecrets(ing)
+
+	// Ingress may now reference other services
+	return c.syncBackends()
 }
 
 func (r IngressDeletedAction) handle(c *CaddyController) error {
@@ -66,5 +97,5 @@ func (r IngressDeletedAction) handle(c *CaddyController) error {
 
 	// delete all resources from caddy config that are associated with this resource
 	c.resourceStore.PluckIngress(r.resource)
-	return nil
+	return c.syncBackends()
 }
@@ -0,0 +1,192 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/caddyserver/ingress/internal/k8s"
+	apiv1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/networking/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// annotationsPrefix is prepended to the keys of IngressClass parameters to get the annotations they default.
+const annotationsPrefix = "caddy.ingress.kubernetes.io/"
+
+// IngressClassChangedAction provides an implementation of the action interface.
+type IngressClassChangedAction struct {
+	resource *v1.IngressClass
+}
+
+// onIngressClassAdded runs when an ingress class is added to the cluster.
+func (c *CaddyController) onIngressClassAdded(obj *v1.IngressClass) {
+	c.syncQueue.Add(IngressClassChangedAction{
+		resource: obj,
+	})
+}
+
+// onIngressClassUpdated is run when an ingress class is updated in the cluster.
+func (c *CaddyController) onIngressClassUpdated(_ *v1.IngressClass, new *v1.IngressClass) {
+	c.syncQueue.Add(IngressClassChangedAction{
+		resource: new,
+	})
+}
+
+// onIngressClassDeleted is run when an ingress class is deleted from the cluster.
+func (c *CaddyController) onIngressClassDeleted(obj *v1.IngressClass) {
+	c.syncQueue.Add(IngressClassChangedAction{
+		resource: obj,
+	})
+}
+
+func (r IngressClassChangedAction) handle(c *CaddyController) error {
+	c.logger.Infof("IngressClass changed (%s)", r.resource.Name)
+
+	delete(c.classParameters, r.resource.Name)
+	if class, ok := latestResource(c.informers.IngressClass, r.resource); ok && c.ingressClasses.IsControllerClass(class) {
+		params, err := c.readClassParameters(class)
+		if err != nil {
+			c.events.Warning(class, reasonInvalidIngressClass, err.Error())
+			return err
+		}
+		c.classParameters[class.Name] = params
+	}
+
+	// the class may now apply to other ingresses, or have other parameters
+	c.syncAllIngresses()
+	if err := c.watchTLSSecrets(); err != nil {
+		return err
+	}
+	return c.syncBackends()
+}
+
+// readClassParameters returns the annotation defaults from the ConfigMap referenced
+// by the IngressClass parameters.
+func (c *CaddyController) readClassParameters(class *v1.IngressClass) (map[string]string, error) {
+	namespace, name, err := c.classParametersRef(class)
+	if err != nil || name == "" {
+		return nil, err
+	}
+
+	informer, err := c.watchClassParameters(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, exists, err := informer.GetIndexer().GetByKey(namespace + "/" + name)
+	if err != nil {
+		return nil, fmt.Errorf("could not get parameters ConfigMap %s/%s: %w", namespace, name, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("parameters ConfigMap %s/%s does not exist", namespace, name)
+	}
+	return obj.(*apiv1.ConfigMap).Data, nil
+}
+
+// classParametersRef returns the namespace and name of the ConfigMap referenced by the
+// IngressClass parameters, or an empty name if it has no parameters.
+func (c *CaddyController) classParametersRef(class *v1.IngressClass) (string, string, error) {
+	params := class.Spec.Parameters
+	if params == nil {
+		return "", "", nil
+	}
+
+	if (params.APIGroup != nil && *params.APIGroup != "") || params.Kind != "ConfigMap" {
+		return "", "", fmt.Errorf("unsupported parameters kind %s, only ConfigMap is supported", params.Kind)
+	}
+
+	namespace := c.resourceStore.ConfigNamespace
+	if params.Namespace != nil && *params.Namespace != "" {
+		namespace = *params.Namespace
+	}
+	return namespace, params.Name, nil
+}
+
+// watchClassParameters starts listening to the ConfigMaps of a namespace holding class parameters,
+// so that classes are synced again when their parameters change.
+// ConfigMaps of the config namespace are already watched for the global options.
+func (c *CaddyController) watchClassParameters(namespace string) (cache.SharedIndexInformer, error) {
+	if informer, ok := c.classParametersInformers[namespace]; ok {
+		return informer, nil
+	}
+
+	factory := c.factories.ConfigNamespace
+	if namespace != c.resourceStore.ConfigNamespace {
+		factory = informers.NewSharedInformerFactoryWithOptions(
+			c.kubeClient,
+			resourcesSyncInterval,
+			informers.WithNamespace(namespace),
+		)
+	}
+
+	informer := k8s.WatchClassParameters(k8s.ClassParametersParams{
+		InformerFactory: factory,
+	}, c.onClassParametersChanged)
+	c.classParametersInformers[namespace] = informer
+
+	// the informer of the config namespace is already running
+	if namespace != c.resourceStore.ConfigNamespace {
+		factory.Start(c.stopChan)
+	}
+	if !cache.WaitForCacheSync(c.stopChan, informer.HasSynced) {
+		return nil, fmt.Errorf("timed out waiting for ConfigMaps of namespace %q to sync", namespace)
+	}
+	return informer, nil
+}
+
+// onClassParametersChanged runs when a ConfigMap is added, updated or deleted in a namespace
+// holding class parameters, and syncs the ingress classes referencing it.
+func (c *CaddyController) onClassParametersChanged(cm *apiv1.ConfigMap) {
+	for _, obj := range c.informers.IngressClass.GetStore().List() {
+		class := obj.(*v1.IngressClass)
+		if !c.ingressClasses.IsControllerClass(class) {
+			continue
+		}
+		if namespace, name, err := c.classParametersRef(class); err == nil && namespace == cm.Namespace && name == cm.Name {
+			c.syncQueue.Add(IngressClassChangedAction{
+				resource: class,
+			})
+		}
+	}
+}
+
+// syncIngress adds the ingress to the store when it is controlled by us, or removes it from the store.
+// It returns the ingress as stored, with the defaults of its class applied.
+func (c *CaddyController) syncIngress(ing *v1.Ingress) (*v1.Ingress, bool) {
+	if !c.matchesIngressSelector(ing) {
+		c.resourceStore.PluckIngress(ing)
+		return nil, false
+	}
+
+	class, ok := c.ingressClasses.Match(ing)
+	if !ok {
+		c.resourceStore.PluckIngress(ing)
+		return nil, false
+	}
+
+	if class != nil {
+		ing = withClassParameters(ing, c.classParameters[class.Name])
+	}
+	c.resourceStore.AddIngress(ing)
+	return ing, true
+}
+
+// withClassParameters returns a copy of the ingress with the annotations
+// it does not set taken from its class parameters.
+func withClassParameters(ing *v1.Ingress, params map[string]string) *v1.Ingress {
+	if len(params) == 0 {
+		return ing
+	}
+
+	ing = ing.DeepCopy()
+	if ing.Annotations == nil {
+		ing.Annotations = map[string]string{}
+	}
+	for key, value := range params {
+		name := annotationsPrefix + key
+		if _, ok := ing.Annotations[name]; !ok {
+			ing.Annotations[name] = value
+		}
+	}
+	return ing
+}
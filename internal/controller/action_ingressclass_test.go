@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/caddyserver/ingress/internal/k8s"
+	"github.com/caddyserver/ingress/pkg/store"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func TestWithClassParameters(t *testing.T) {
+	ing := &v1.Ingress{ObjectMeta: metav1.ObjectMeta{
+		Annotations: map[string]string{"caddy.ingress.kubernetes.io/rewrite-to": "/ingress"},
+	}}
+
+	withDefaults := withClassParameters(ing, map[string]string{
+		"rewrite-to":       "/class",
+		"backend-protocol": "https",
+	})
+
+	require.Equal(t, map[string]string{
+		"caddy.ingress.kubernetes.io/rewrite-to":       "/ingress",
+		"caddy.ingress.kubernetes.io/backend-protocol": "https",
+	}, withDefaults.Annotations)
+	require.Len(t, ing.Annotations, 1, "the ingress from the informer cache must not be modified")
+
+	require.Same(t, ing, withClassParameters(ing, nil))
+}
+
+func TestClassParametersAreWatched(t *testing.T) {
+	cm := &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "caddy-internal-defaults", Namespace: "caddy-system"},
+		Data:       map[string]string{"backend-protocol": "https"},
+	}
+	class := &v1.IngressClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "caddy-internal"},
+		Spec: v1.IngressClassSpec{
+			Controller: "caddy.io/ingress-controller",
+			Parameters: &v1.IngressClassParametersReference{Kind: "ConfigMap", Name: cm.Name},
+		},
+	}
+	client := fake.NewClientset(cm, class)
+
+	stopChan := make(chan struct{})
+	defer close(stopChan)
+	configNamespace := informers.NewSharedInformerFactoryWithOptions(client, 0, informers.WithNamespace("caddy-system"))
+	configNamespace.Core().V1().ConfigMaps().Informer()
+	cluster := informers.NewSharedInformerFactory(client, 0)
+	classInformer := cluster.Networking().V1().IngressClasses().Informer()
+	configNamespace.Start(stopChan)
+	cluster.Start(stopChan)
+	cluster.WaitForCacheSync(stopChan)
+
+	queue := workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[Action]())
+	defer queue.ShutDown()
+	c := &CaddyController{
+		logger:                   zap.NewNop().Sugar(),
+		stopChan:                 stopChan,
+		syncQueue:                queue,
+		factories:                &InformerFactory{ConfigNamespace: configNamespace},
+		informers:                &Informer{IngressClass: classInformer},
+		ingressClasses:           k8s.IngressClassMatcher{ControllerName: "caddy.io/ingress-controller"},
+		resourceStore:            store.NewStore(store.Options{}, "caddy-system", &store.PodInfo{}),
+		classParametersInformers: map[string]cache.SharedIndexInformer{},
+	}
+
+	params, err := c.readClassParameters(class)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"backend-protocol": "https"}, params)
+
+	// the class is synced again when its parameters change
+	cm = cm.DeepCopy()
+	cm.Data["backend-protocol"] = "h2c"
+	_, err = client.CoreV1().ConfigMaps("caddy-system").Update(context.Background(), cm, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		params, err := c.readClassParameters(class)
+		return err == nil && params["backend-protocol"] == "h2c" && queue.Len() > 0
+	}, time.Second, 10*time.Millisecond)
+
+	for queue.Len() > 0 {
+		action, _ := queue.Get()
+		require.IsType(t, IngressClassChangedAction{}, action)
+		require.Equal(t, "caddy-internal", action.(IngressClassChangedAction).resource.Name)
+		queue.Done(action)
+	}
+
+	// other ConfigMaps are ignored
+	_, err = client.CoreV1().ConfigMaps("caddy-system").Create(context.Background(), &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "caddy-system"},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+	require.Zero(t, queue.Len())
+}
@@ -0,0 +1,47 @@
+package controller
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// NamespaceChangedAction provides an implementation of the action interface.
+type NamespaceChangedAction struct {
+	resource *v1.Namespace
+}
+
+// onNamespaceAdded runs when a namespace is added to the cluster.
+func (c *CaddyController) onNamespaceAdded(obj *v1.Namespace) {
+	c.syncQueue.Add(NamespaceChangedAction{
+		resource: obj,
+	})
+}
+
+// onNamespaceUpdated is run when a namespace is updated in the cluster.
+func (c *CaddyController) onNamespaceUpdated(old *v1.Namespace, new *v1.Namespace) {
+	// only labels decide whether a namespace is watched
+	if labels.Equals(old.Labels, new.Labels) {
+		return
+	}
+	c.syncQueue.Add(NamespaceChangedAction{
+		resource: new,
+	})
+}
+
+// onNamespaceDeleted is run when a namespace is deleted from the cluster.
+func (c *CaddyController) onNamespaceDeleted(obj *v1.Namespace) {
+	c.syncQueue.Add(NamespaceChangedAction{
+		resource: obj,
+	})
+}
+
+func (r NamespaceChangedAction) handle(c *CaddyController) error {
+	ns, ok := latestResource(c.informers.Namespace, r.resource)
+	if !ok || !c.isWatchedNamespace(ns) {
+		c.unwatchNamespace(r.resource.Name)
+		return c.syncBackends()
+	}
+
+	_, err := c.watchNamespace(ns.Name)
+	return err
+}
@@ -0,0 +1,9 @@
+package controller
+
+// ReloadAction provides an implementation of the action interface.
+// It does not change the store, it is queued to retry loading the caddy config.
+type ReloadAction struct{}
+
+func (r ReloadAction) handle(c *CaddyController) error {
+	return nil
+}
@@ -0,0 +1,282 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/caddyserver/ingress/internal/k8s"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+var resourceFolder = ""
+
+// GetResourceFolder returns the path where the ConfigMaps served by Resource backends are stored as files.
+func GetResourceFolder() string {
+	if resourceFolder == "" {
+		// Use the systemd cache directory if possible.
+		runtimeDir := os.Getenv("RUNTIME_DIRECTORY")
+		if runtimeDir != "" {
+			resourceFolder = filepath.Join(runtimeDir, "resources")
+		} else {
+			resourceFolder = filepath.FromSlash("/etc/caddy/resources")
+		}
+	}
+	return resourceFolder
+}
+
+// ResourceDir returns the directory holding the files of a ConfigMap served by Resource backends,
+// one file per key.
+func ResourceDir(namespace, name string) string {
+	return filepath.Join(GetResourceFolder(), namespace, name)
+}
+
+// ResourceConfigMapAddedAction provides an implementation of the action interface.
+type ResourceConfigMapAddedAction struct {
+	resource *apiv1.ConfigMap
+}
+
+// ResourceConfigMapUpdatedAction provides an implementation of the action interface.
+type ResourceConfigMapUpdatedAction struct {
+	resource    *apiv1.ConfigMap
+	oldResource *apiv1.ConfigMap
+}
+
+// ResourceConfigMapDeletedAction provides an implementation of the action interface.
+type ResourceConfigMapDeletedAction struct {
+	resource *apiv1.ConfigMap
+}
+
+// onResourceConfigMapAdded runs when a ConfigMap served by Resource backends is added.
+func (c *CaddyController) onResourceConfigMapAdded(obj *apiv1.ConfigMap) {
+	c.syncQueue.Add(ResourceConfigMapAddedAction{
+		resource: obj,
+	})
+}
+
+// onResourceConfigMapUpdated is run when a ConfigMap served by Resource backends is updated.
+func (c *CaddyController) onResourceConfigMapUpdated(old *apiv1.ConfigMap, new *apiv1.ConfigMap) {
+	// periodic resyncs do not change content
+	if old.ResourceVersion == new.ResourceVersion {
+		return
+	}
+	c.syncQueue.Add(ResourceConfigMapUpdatedAction{
+		resource:    new,
+		oldResource: old,
+	})
+}
+
+// onResourceConfigMapDeleted is run when a ConfigMap served by Resource backends is deleted.
+func (c *CaddyController) onResourceConfigMapDeleted(obj *apiv1.ConfigMap) {
+	c.syncQueue.Add(ResourceConfigMapDeletedAction{
+		resource: obj,
+	})
+}
+
+func (r ResourceConfigMapAddedAction) handle(c *CaddyController) error {
+	return c.syncResourceConfigMap(r.resource)
+}
+
+func (r ResourceConfigMapUpdatedAction) handle(c *CaddyController) error {
+	return c.syncResourceConfigMap(r.resource)
+}
+
+func (r ResourceConfigMapDeletedAction) handle(c *CaddyController) error {
+	c.resourceStore.PluckResourceConfigMap(r.resource)
+	return c.removeResourceFiles(r.resource.Namespace, r.resource.Name)
+}
+
+// resourceConfigMapWatch watches a single ConfigMap served by Resource backends.
+type resourceConfigMapWatch struct {
+	informer cache.SharedIndexInformer
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+// syncResourceConfigMap stores the ConfigMap and its files if it is served by an ingress.
+func (c *CaddyController) syncResourceConfigMap(cm *apiv1.ConfigMap) error {
+	cm, ok := latestResource(c.resourceConfigMapInformer(cm.Namespace, cm.Name), cm)
+	if !ok || !c.resourceStore.IsResourceConfigMap(cm.Namespace, cm.Name) {
+		c.resourceStore.PluckResourceConfigMap(cm)
+		return c.removeResourceFiles(cm.Namespace, cm.Name)
+	}
+
+	c.logger.Infof("served ConfigMap changed (%s/%s)", cm.Namespace, cm.Name)
+	c.resourceStore.AddResourceConfigMap(cm)
+	return c.writeResourceFiles(cm)
+}
+
+// syncResources watches the ConfigMaps served by Resource backends, stops watching the others,
+// and syncs the store and the resource folder with the served ConfigMaps.
+func (c *CaddyController) syncResources() error {
+	keys := c.resourceStore.ResourceConfigMapKeys()
+
+	// the informers of a ConfigMap stop with the informers of its namespace
+	for key, w := range c.resourceConfigMaps {
+		if !slices.Contains(keys, key) || w.ctx.Err() != nil {
+			w.cancel()
+			delete(c.resourceConfigMaps, key)
+		}
+	}
+
+	if len(keys) > 0 {
+		if err := os.MkdirAll(GetResourceFolder(), 0755); err != nil {
+			return err
+		}
+	}
+
+	configMaps := []*apiv1.ConfigMap{}
+	served := map[string]bool{}
+	for _, key := range keys {
+		namespace, name, _ := strings.Cut(key, "/")
+		w, err := c.watchResourceConfigMap(namespace, name)
+		if err != nil {
+			return err
+		}
+		if w == nil {
+			continue
+		}
+
+		obj, exists, err := w.informer.GetStore().GetByKey(key)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+		cm := obj.(*apiv1.ConfigMap)
+		if err := c.writeResourceFiles(cm); err != nil {
+			return err
+		}
+		configMaps = append(configMaps, cm)
+		served[key] = true
+	}
+	c.resourceStore.ResourceConfigMaps = configMaps
+
+	// remove the files of the ConfigMaps that are not served anymore
+	for key, cm := range c.resourceFiles {
+		if !served[key] {
+			if err := c.removeResourceFiles(cm.Namespace, cm.Name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// watchResourceConfigMap starts listening to a ConfigMap served by Resource backends. Only
+// this ConfigMap is listed and watched, not all the ConfigMaps of its namespace.
+// It returns nil if the namespace of the ConfigMap is not watched.
+func (c *CaddyController) watchResourceConfigMap(namespace, name string) (*resourceConfigMapWatch, error) {
+	key := namespace + "/" + name
+	if w, ok := c.resourceConfigMaps[key]; ok {
+		return w, nil
+	}
+
+	ns := c.namespaceInformersFor(namespace)
+	if ns == nil {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithCancel(ns.ctx)
+	factory := informers.NewSharedInformerFactoryWithOptions(
+		c.kubeClient,
+		resourcesSyncInterval,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(o *metav1.ListOptions) {
+			o.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+		}),
+	)
+	w := &resourceConfigMapWatch{
+		informer: k8s.WatchResourceConfigMap(k8s.ResourceConfigMapParams{
+			InformerFactory: factory,
+			ConfigMapName:   name,
+		}, k8s.ConfigMapHandlers{
+			AddFunc:    c.onResourceConfigMapAdded,
+			UpdateFunc: c.onResourceConfigMapUpdated,
+			DeleteFunc: c.onResourceConfigMapDeleted,
+		}),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), w.informer.HasSynced) {
+		cancel()
+		return nil, fmt.Errorf("timed out waiting for ConfigMap %s cache to sync", key)
+	}
+	c.resourceConfigMaps[key] = w
+	return w, nil
+}
+
+// writeResourceFiles writes each key of the ConfigMap to a file of its resource directory,
+// and removes the files of keys that are gone.
+func (c *CaddyController) writeResourceFiles(cm *apiv1.ConfigMap) error {
+	key := cm.Namespace + "/" + cm.Name
+	if written, ok := c.resourceFiles[key]; ok && written.ResourceVersion == cm.ResourceVersion {
+		return nil
+	}
+
+	dir := ResourceDir(cm.Namespace, cm.Name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	files := map[string][]byte{}
+	for name, content := range cm.Data {
+		files[name] = []byte(content)
+	}
+	for name, content := range cm.BinaryData {
+		files[name] = content
+	}
+
+	for name, content := range files {
+		// caddy must never serve a partially written file
+		tmp, err := os.CreateTemp(dir, ".tmp-")
+		if err != nil {
+			return err
+		}
+		_, err = tmp.Write(content)
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Chmod(tmp.Name(), 0644)
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), filepath.Join(dir, name))
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+			return err
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if _, ok := files[entry.Name()]; !ok {
+			if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+
+	c.resourceFiles[key] = cm
+	return nil
+}
+
+// removeResourceFiles removes the resource directory of a ConfigMap.
+func (c *CaddyController) removeResourceFiles(namespace, name string) error {
+	delete(c.resourceFiles, namespace+"/"+name)
+	return os.RemoveAll(ResourceDir(namespace, name))
+}
@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/caddyserver/ingress/pkg/store"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// useResourceFolder stores resource files in a temporary folder for the duration of the test.
+func useResourceFolder(t *testing.T) {
+	previous := resourceFolder
+	resourceFolder = t.TempDir()
+	t.Cleanup(func() { resourceFolder = previous })
+}
+
+// readResourceFiles returns the content of the files of a ConfigMap by file name.
+func readResourceFiles(t *testing.T, namespace, name string) map[string]string {
+	entries, err := os.ReadDir(ResourceDir(namespace, name))
+	require.NoError(t, err)
+
+	files := map[string]string{}
+	for _, entry := range entries {
+		content, err := os.ReadFile(filepath.Join(ResourceDir(namespace, name), entry.Name()))
+		require.NoError(t, err)
+		files[entry.Name()] = string(content)
+	}
+	return files
+}
+
+// resourceIngress returns an ingress serving the ConfigMap.
+func resourceIngress(namespace, configMap string) *networkingv1.Ingress {
+	return &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "pages", Namespace: namespace, UID: "pages"},
+		Spec: networkingv1.IngressSpec{
+			DefaultBackend: &networkingv1.IngressBackend{
+				Resource: &apiv1.TypedLocalObjectReference{Kind: "ConfigMap", Name: configMap},
+			},
+		},
+	}
+}
+
+func newResourceTestController(t *testing.T, client *fake.Clientset) *CaddyController {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	c := &CaddyController{
+		logger:             zap.NewNop().Sugar(),
+		kubeClient:         client,
+		syncQueue:          workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[Action]()),
+		resourceStore:      store.NewStore(store.Options{}, "caddy-system", nil),
+		namespaces:         map[string]*namespaceInformers{"default": {ctx: ctx, cancel: cancel}},
+		resourceConfigMaps: map[string]*resourceConfigMapWatch{},
+		resourceFiles:      map[string]*apiv1.ConfigMap{},
+	}
+	t.Cleanup(c.syncQueue.ShutDown)
+	return c
+}
+
+func TestWriteResourceFiles(t *testing.T) {
+	useResourceFolder(t)
+	c := &CaddyController{resourceFiles: map[string]*apiv1.ConfigMap{}}
+
+	cm := &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "pages", Namespace: "default", ResourceVersion: "1"},
+		Data: map[string]string{
+			"index.html": "<h1>Down for maintenance</h1>",
+			"robots.txt": "User-agent: *",
+		},
+		BinaryData: map[string][]byte{"favicon.ico": {0x00, 0x01}},
+	}
+	require.NoError(t, c.writeResourceFiles(cm))
+	require.Equal(t, map[string]string{
+		"index.html":  "<h1>Down for maintenance</h1>",
+		"robots.txt":  "User-agent: *",
+		"favicon.ico": "\x00\x01",
+	}, readResourceFiles(t, "default", "pages"))
+
+	info, err := os.Stat(filepath.Join(ResourceDir("default", "pages"), "index.html"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0644), info.Mode().Perm())
+
+	// files of removed keys are removed, and no temporary file is left
+	updated := cm.DeepCopy()
+	updated.ResourceVersion = "2"
+	updated.Data = map[string]string{"index.html": "<h1>Back soon</h1>"}
+	updated.BinaryData = nil
+	require.NoError(t, c.writeResourceFiles(updated))
+	require.Equal(t, map[string]string{"index.html": "<h1>Back soon</h1>"}, readResourceFiles(t, "default", "pages"))
+
+	// files are only written when the ConfigMap changed
+	require.NoError(t, os.WriteFile(filepath.Join(ResourceDir("default", "pages"), "index.html"), []byte("edited"), 0644))
+	require.NoError(t, c.writeResourceFiles(updated))
+	require.Equal(t, map[string]string{"index.html": "edited"}, readResourceFiles(t, "default", "pages"))
+}
+
+func TestSyncResourcesWatchesServedConfigMaps(t *testing.T) {
+	useResourceFolder(t)
+	client := fake.NewClientset(
+		&apiv1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "pages", Namespace: "default", UID: "pages", ResourceVersion: "1"},
+			Data:       map[string]string{"index.html": "<h1>Down for maintenance</h1>"},
+		},
+		&apiv1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default", UID: "other", ResourceVersion: "1"},
+			Data:       map[string]string{"app.conf": "not served"},
+		},
+	)
+	c := newResourceTestController(t, client)
+
+	// ConfigMaps are not watched without Resource backends
+	require.NoError(t, c.syncResources())
+	require.Empty(t, c.resourceConfigMaps)
+
+	// only the served ConfigMap is watched and written
+	ing := resourceIngress("default", "pages")
+	c.resourceStore.AddIngress(ing)
+	require.NoError(t, c.syncResources())
+	require.Len(t, c.resourceConfigMaps, 1)
+	require.Contains(t, c.resourceConfigMaps, "default/pages")
+	require.NotNil(t, c.resourceStore.GetResourceConfigMap("default", "pages"))
+	require.Equal(t, map[string]string{"index.html": "<h1>Down for maintenance</h1>"}, readResourceFiles(t, "default", "pages"))
+	require.NoDirExists(t, ResourceDir("default", "other"))
+
+	// the files are removed and the watch stopped once the ConfigMap is not served anymore
+	watch := c.resourceConfigMaps["default/pages"]
+	c.resourceStore.PluckIngress(ing)
+	require.NoError(t, c.syncResources())
+	require.Empty(t, c.resourceConfigMaps)
+	require.Error(t, watch.ctx.Err())
+	require.Nil(t, c.resourceStore.GetResourceConfigMap("default", "pages"))
+	require.NoDirExists(t, ResourceDir("default", "pages"))
+	require.Empty(t, c.resourceFiles)
+}
+
+func TestDeletedResourceConfigMapIsRemoved(t *testing.T) {
+	useResourceFolder(t)
+	cm := &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "pages", Namespace: "default", UID: "pages", ResourceVersion: "1"},
+		Data:       map[string]string{"index.html": "<h1>Down for maintenance</h1>"},
+	}
+	c := newResourceTestController(t, fake.NewClientset(cm))
+	c.resourceStore.AddIngress(resourceIngress("default", "pages"))
+	require.NoError(t, c.syncResources())
+	require.DirExists(t, ResourceDir("default", "pages"))
+
+	require.NoError(t, ResourceConfigMapDeletedAction{resource: cm}.handle(c))
+	require.Nil(t, c.resourceStore.GetResourceConfigMap("default", "pages"))
+	require.NoDirExists(t, ResourceDir("default", "pages"))
+	require.Empty(t, c.resourceFiles)
+}
@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+)
+
+// ServiceAddedAction provides an implementation of the action interface.
+type ServiceAddedAction struct {
+	resource *apiv1.Service
+}
+
+// ServiceUpdatedAction provides an implementation of the action interface.
+type ServiceUpdatedAction struct {
+	resource    *apiv1.Service
+	oldResource *apiv1.Service
+}
+
+// ServiceDeletedAction provides an implementation of the action interface.
+type ServiceDeletedAction struct {
+	resource *apiv1.Service
+}
+
+// onServiceAdded runs when a service is added to the cluster.
+func (c *CaddyController) onServiceAdded(obj *apiv1.Service) {
+	c.syncQueue.Add(ServiceAddedAction{
+		resource: obj,
+	})
+}
+
+// onServiceUpdated is run when a service is updated in the cluster.
+func (c *CaddyController) onServiceUpdated(old *apiv1.Service, new *apiv1.Service) {
+	// periodic resyncs do not change services
+	if old.ResourceVersion == new.ResourceVersion {
+		return
+	}
+	c.syncQueue.Add(ServiceUpdatedAction{
+		resource:    new,
+		oldResource: old,
+	})
+}
+
+// onServiceDeleted is run when a service is deleted from the cluster.
+func (c *CaddyController) onServiceDeleted(obj *apiv1.Service) {
+	c.syncQueue.Add(ServiceDeletedAction{
+		resource: obj,
+	})
+}
+
+func (r ServiceAddedAction) handle(c *CaddyController) error {
+	c.syncService(r.resource)
+	return nil
+}
+
+func (r ServiceUpdatedAction) handle(c *CaddyController) error {
+	c.syncService(r.resource)
+	return nil
+}
+
+func (r ServiceDeletedAction) handle(c *CaddyController) error {
+	c.resourceStore.PluckService(r.resource)
+	return nil
+}
+
+// syncService adds the service to the store if it is referenced by an ingress.
+func (c *CaddyController) syncService(svc *apiv1.Service) {
+	svc, ok := latestResource(c.serviceInformer(svc.Namespace), svc)
+	if !ok || !c.resourceStore.IsReferencedService(svc.Namespace, svc.Name) {
+		c.resourceStore.PluckService(svc)
+		return
+	}
+
+	c.logger.Debugf("Service changed (%s/%s)", svc.Namespace, svc.Name)
+	c.resourceStore.AddService(svc)
+}
+
+// syncBackends syncs the store with the services referenced by ingresses, with the
+// endpoint slices of the ones routed to pods, and with the ConfigMaps served by ingresses.
+// It must be called when ingresses or the global options change.
+func (c *CaddyController) syncBackends() error {
+	services := []*apiv1.Service{}
+	for _, ns := range c.namespaces {
+		for _, obj := range ns.Service.GetStore().List() {
+			svc := obj.(*apiv1.Service)
+			if c.resourceStore.IsReferencedService(svc.Namespace, svc.Name) {
+				services = append(services, svc)
+			}
+		}
+	}
+	c.resourceStore.Services = services
+	c.checkDefaultBackend()
+
+	if err := c.syncResources(); err != nil {
+		return err
+	}
+	return c.syncPodRouting()
+}
+
+// checkDefaultBackend reports a controller-wide default backend in a namespace that is not watched
+// on the controller pod, its service and endpoint slices are unknown so it cannot be routed to.
+func (c *CaddyController) checkDefaultBackend() {
+	def := c.resourceStore.DefaultBackend()
+	if def == nil || c.namespaceInformersFor(def.Namespace) != nil {
+		return
+	}
+
+	msg := fmt.Sprintf("default backend service %s/%s is in namespace %s which is not watched", def.Namespace, def.Spec.DefaultBackend.Service.Name, def.Namespace)
+	c.logger.Warn(msg)
+	c.events.Warning(c.eventObject(nil), reasonDefaultBackendNotWatched, msg)
+}
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/caddyserver/ingress/pkg/store"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCheckDefaultBackend(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		defaultBackend string
+		namespaces     []string
+		expectedEvents []string
+	}{
+		{
+			desc:       "no default backend",
+			namespaces: []string{"blue"},
+		},
+		{
+			desc:           "all namespaces watched",
+			defaultBackend: "fallback/not-found:http",
+			namespaces:     []string{""},
+		},
+		{
+			desc:           "namespace watched",
+			defaultBackend: "fallback/not-found:http",
+			namespaces:     []string{"blue", "fallback"},
+		},
+		{
+			desc:           "namespace not watched",
+			defaultBackend: "fallback/not-found:http",
+			namespaces:     []string{"blue"},
+			expectedEvents: []string{
+				"Warning DefaultBackendNotWatched default backend service fallback/not-found is in namespace fallback which is not watched",
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			events, fakeRecorder, _ := newFakeEventRecorder(time.Now())
+			c := &CaddyController{
+				logger: zap.NewNop().Sugar(),
+				events: events,
+				resourceStore: store.NewStore(store.Options{DefaultBackendService: tC.defaultBackend}, "caddy-system", &store.PodInfo{
+					Name:      "caddy-ingress-controller",
+					Namespace: "caddy-system",
+					UID:       "pod",
+				}),
+				namespaces: map[string]*namespaceInformers{},
+			}
+			for _, ns := range tC.namespaces {
+				c.namespaces[ns] = &namespaceInformers{}
+			}
+
+			c.checkDefaultBackend()
+			require.Equal(t, tC.expectedEvents, recordedEvents(fakeRecorder))
+		})
+	}
+}
@@ -1,11 +1,13 @@
 package controller
 
 import (
+	"errors"
 	"net"
 	"sort"
 	"strings"
 
 	"github.com/caddyserver/ingress/internal/k8s"
+	"github.com/caddyserver/ingress/internal/metrics"
 	"go.uber.org/zap"
 	"gopkg.in/go-playground/pool.v3"
 	networkingv1 "k8s.io/api/networking/v1"
@@ -13,8 +15,11 @@ import (
 )
 
 // dispatchSync is run every syncInterval duration to sync ingress source address fields.
+// Only the leader publishes ingress statuses.
 func (c *CaddyController) dispatchSync() {
-	c.syncQueue.Add(SyncStatusAction{})
+	if c.IsLeader() {
+		c.syncQueue.Add(SyncStatusAction{})
+	}
 }
 
 // SyncStatusAction provides an implementation of the action interface.
@@ -23,6 +28,10 @@ type SyncStatusAction struct {
 
 // handle is run when a syncStatusAction appears in the queue.
 func (r SyncStatusAction) handle(c *CaddyController) error {
+	// leadership may have been lost while the action was queued
+	if !c.IsLeader() {
+		return nil
+	}
 	return c.syncStatus(c.resourceStore.Ingresses)
 }
 
@@ -34,14 +43,13 @@ func (c *CaddyController) syncStatus(ings []*networkingv1.Ingress) error {
 	}
 
 	c.logger.Debugf("Syncing %d Ingress resources source addresses", len(ings))
-	c.updateIngStatuses(sliceToLoadBalancerIngress(addrs), ings)
-
-	return nil
+	return c.updateIngStatuses(sliceToLoadBalancerIngress(addrs), ings)
 }
 
 // updateIngStatuses starts a queue and adds all monitored ingresses to update their status source address to the on
 // that the ingress controller is running on. This is called by the syncStatus queue.
-func (c *CaddyController) updateIngStatuses(controllerAddresses []networkingv1.IngressLoadBalancerIngress, ings []*networkingv1.Ingress) {
+// It returns the errors of the updates that failed.
+func (c *CaddyController) updateIngStatuses(controllerAddresses []networkingv1.IngressLoadBalancerIngress, ings []*networkingv1.Ingress) error {
 	p := pool.NewLimited(10)
 	defer p.Close()
 
@@ -62,11 +70,19 @@ func (c *CaddyController) updateIngStatuses(controllerAddresses []networkingv1.I
 	}
 
 	batch.QueueComplete()
-	batch.WaitAll()
+
+	var errs []error
+	for wu := range batch.Results() {
+		if err := wu.Error(); err != nil {
+			metrics.StatusUpdateFailuresTotal.Inc()
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
 }
 
 // runUpdate updates the ingress status field.
-func runUpdate(logger *zap.SugaredLogger, ing *networkingv1.Ingress, status []networkingv1.IngressLoadBalancerIngress, client *kubernetes.Clientset) pool.WorkFunc {
+func runUpdate(logger *zap.SugaredLogger, ing *networkingv1.Ingress, status []networkingv1.IngressLoadBalancerIngress, client kubernetes.Interface) pool.WorkFunc {
 	return func(wu pool.WorkUnit) (any, error) {
 		if wu.IsCancelled() {
 			return nil, nil
@@ -75,16 +91,17 @@ func runUpdate(logger *zap.SugaredLogger, ing *networkingv1.Ingress, status []ne
 		updated, err := k8s.UpdateIngressStatus(client, ing, status)
 		if err != nil {
 			logger.Warnf("error updating ingress rule: %v", err)
-		} else {
-			logger.Debugf(
-				"updating Ingress %v/%v status from %v to %v",
-				ing.Namespace,
-				ing.Name,
-				ing.Status.LoadBalancer.Ingress,
-				updated.Status.LoadBalancer.Ingress,
-			)
+			return nil, err
 		}
 
+		logger.Debugf(
+			"updating Ingress %v/%v status from %v to %v",
+			ing.Namespace,
+			ing.Name,
+			ing.Status.LoadBalancer.Ingress,
+			updated.Status.LoadBalancer.Ingress,
+		)
+
 		return true, nil
 	}
 }
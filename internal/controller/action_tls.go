@@ -1,11 +1,16 @@
 package controller
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/caddyserver/ingress/internal/k8s"
 	apiv1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/cache"
 )
 
 var certFolder = ""
@@ -66,6 +71,12 @@ func (c *CaddyController) onSecretDeleted(obj *apiv1.Secret) {
 	})
 }
 
+// certFile returns the path of the .pem file of a secret. Namespaces and names cannot
+// contain an underscore, so secrets with the same name in different namespaces do not collide.
+func certFile(s *apiv1.Secret) string {
+	return filepath.Join(GetCertFolder(), s.Namespace+"_"+s.Name+".pem")
+}
+
 // writeFile writes a secret to a .pem file on disk.
 func writeFile(s *apiv1.Secret) error {
 	content := make([]byte, 0)
@@ -74,7 +85,7 @@ func writeFile(s *apiv1.Secret) error {
 		content = append(content, cert...)
 	}
 
-	err := os.WriteFile(filepath.Join(GetCertFolder(), s.Name+".pem"), content, 0644)
+	err := os.WriteFile(certFile(s), content, 0644)
 	if err != nil {
 		return err
 	}
@@ -83,53 +94,143 @@ func writeFile(s *apiv1.Secret) error {
 }
 
 func (r SecretAddedAction) handle(c *CaddyController) error {
-	c.logger.Infof("TLS secret created (%s/%s)", r.resource.Namespace, r.resource.Name)
-	return writeFile(r.resource)
+	secret, ok := latestResource(c.tlsSecretInformer(r.resource.Namespace), r.resource)
+	if !ok {
+		c.logger.Debugf("skipping deleted TLS secret (%s/%s)", r.resource.Namespace, r.resource.Name)
+		return nil
+	}
+
+	c.logger.Infof("TLS secret created (%s/%s)", secret.Namespace, secret.Name)
+	c.reportInvalidTLSSecret(secret)
+	return writeFile(secret)
 }
 
 func (r SecretUpdatedAction) handle(c *CaddyController) error {
-	c.logger.Infof("TLS secret updated (%s/%s)", r.resource.Namespace, r.resource.Name)
-	return writeFile(r.resource)
+	secret, ok := latestResource(c.tlsSecretInformer(r.resource.Namespace), r.resource)
+	if !ok {
+		c.logger.Debugf("skipping deleted TLS secret (%s/%s)", r.resource.Namespace, r.resource.Name)
+		return nil
+	}
+
+	c.logger.Infof("TLS secret updated (%s/%s)", secret.Namespace, secret.Name)
+	c.reportInvalidTLSSecret(secret)
+	return writeFile(secret)
 }
 
 func (r SecretDeletedAction) handle(c *CaddyController) error {
 	c.logger.Infof("TLS secret deleted (%s/%s)", r.resource.Namespace, r.resource.Name)
-	return os.Remove(filepath.Join(GetCertFolder(), r.resource.Name+".pem"))
+	for _, ing := range k8s.IngressesUsingTLSSecret(r.resource, c.resourceStore.Ingresses) {
+		c.events.Warning(ing, reasonTLSSecretMissing, fmt.Sprintf("TLS secret %s was deleted", r.resource.Name))
+	}
+	// the file may already be removed if the action is retried
+	return removeFile(r.resource)
+}
+
+// removeFile removes the .pem file of a secret from disk, if it exists.
+func removeFile(s *apiv1.Secret) error {
+	err := os.Remove(certFile(s))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// reportInvalidTLSSecret records an event on each ingress using the secret if it is not usable.
+func (c *CaddyController) reportInvalidTLSSecret(secret *apiv1.Secret) {
+	err := k8s.ValidateTLSSecret(secret, time.Now())
+	if err == nil {
+		return
+	}
+
+	c.logger.Warn(err.Error())
+	for _, ing := range k8s.IngressesUsingTLSSecret(secret, c.resourceStore.Ingresses) {
+		c.events.Warning(ing, reasonTLSSecretInvalid, err.Error())
+	}
+}
+
+// checkTLSSecrets records an event on the ingress for each referenced TLS secret
+// that does not exist or is not usable.
+func (c *CaddyController) checkTLSSecrets(ing *networkingv1.Ingress) {
+	ns := c.namespaceInformersFor(ing.Namespace)
+	if ns == nil || ns.TLSSecret == nil {
+		return
+	}
+
+	lister := ns.factory.Core().V1().Secrets().Lister()
+	for _, tlsRule := range ing.Spec.TLS {
+		if tlsRule.SecretName == "" {
+			continue
+		}
+
+		secret, err := lister.Secrets(ing.Namespace).Get(tlsRule.SecretName)
+		if errors.IsNotFound(err) {
+			c.events.Warning(ing, reasonTLSSecretMissing, fmt.Sprintf("TLS secret %s does not exist", tlsRule.SecretName))
+			continue
+		}
+		if err != nil {
+			c.logger.Warnf("could not get TLS secret %s/%s: %v", ing.Namespace, tlsRule.SecretName, err)
+			continue
+		}
+
+		if err := k8s.ValidateTLSSecret(secret, time.Now()); err != nil {
+			c.events.Warning(ing, reasonTLSSecretInvalid, err.Error())
+		}
+	}
 }
 
 // watchTLSSecrets Start listening to TLS secrets if at least one ingress needs it.
 // It will sync the CertFolder with TLS secrets
 func (c *CaddyController) watchTLSSecrets() error {
-	if c.informers.TLSSecret == nil && c.resourceStore.HasManagedTLS() {
-		if err := os.MkdirAll(GetCertFolder(), 0755); err != nil && !os.IsExist(err) {
+	if c.watchingTLSSecrets || !c.resourceStore.HasManagedTLS() {
+		return nil
+	}
+
+	if err := os.MkdirAll(GetCertFolder(), 0755); err != nil && !os.IsExist(err) {
+		return err
+	}
+
+	c.watchingTLSSecrets = true
+	for _, ns := range c.namespaces {
+		if err := c.watchNamespaceTLSSecrets(ns); err != nil {
 			return err
 		}
+	}
+	return nil
+}
 
-		// Init informers
-		params := k8s.TLSSecretParams{
-			InformerFactory: c.factories.WatchedNamespace,
-		}
-		c.informers.TLSSecret = k8s.WatchTLSSecrets(params, k8s.TLSSecretHandlers{
-			AddFunc:    c.onSecretAdded,
-			UpdateFunc: c.onSecretUpdated,
-			DeleteFunc: c.onSecretDeleted,
-		})
+// watchNamespaceTLSSecrets starts listening to the TLS secrets of a watched namespace
+// and syncs the CertFolder with them.
+func (c *CaddyController) watchNamespaceTLSSecrets(ns *namespaceInformers) error {
+	if ns.TLSSecret != nil {
+		return nil
+	}
 
-		// Run it
-		go c.informers.TLSSecret.Run(c.stopChan)
+	// Init informers
+	params := k8s.TLSSecretParams{
+		InformerFactory: ns.factory,
+	}
+	ns.TLSSecret = k8s.WatchTLSSecrets(params, k8s.TLSSecretHandlers{
+		AddFunc:    c.onSecretAdded,
+		UpdateFunc: c.onSecretUpdated,
+		DeleteFunc: c.onSecretDeleted,
+	})
 
-		// Sync secrets
-		secrets, err := k8s.ListTLSSecrets(params, c.resourceStore.Ingresses)
-		if err != nil {
-			return err
-		}
+	// Run it
+	ns.factory.Start(ns.ctx.Done())
+	if !cache.WaitForCacheSync(ns.ctx.Done(), ns.TLSSecret.HasSynced) {
+		return fmt.Errorf("timed out waiting for TLS secrets cache to sync")
+	}
 
-		for _, secret := range secrets {
-			if err := writeFile(secret); err != nil {
-				return err
-			}
-		}
+	// Sync secrets
+	secrets, err := k8s.ListTLSSecrets(params, c.resourceStore.Ingresses)
+	if err != nil {
+		return err
 	}
 
+	for _, secret := range secrets {
+		if err := writeFile(secret); err != nil {
+			return err
+		}
+	}
 	return nil
 }
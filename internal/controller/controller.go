@@ -7,14 +7,25 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/caddyserver/caddy/v2"
 	"github.com/caddyserver/certmagic"
+	"github.com/caddyserver/ingress/internal/debug"
+	"github.com/caddyserver/ingress/internal/health"
 	"github.com/caddyserver/ingress/internal/k8s"
+	"github.com/caddyserver/ingress/internal/metrics"
+	"github.com/caddyserver/ingress/pkg/converter"
 	"github.com/caddyserver/ingress/pkg/store"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/informers"
@@ -24,6 +35,7 @@ import (
 
 	// load required caddy plugins
 	_ "github.com/caddyserver/caddy/v2/modules/caddyhttp/proxyprotocol"
+	_ "github.com/caddyserver/caddy/v2/modules/caddyhttp/requestbody"
 	_ "github.com/caddyserver/caddy/v2/modules/caddyhttp/reverseproxy"
 	_ "github.com/caddyserver/caddy/v2/modules/caddytls"
 	_ "github.com/caddyserver/caddy/v2/modules/caddytls/standardstek"
@@ -39,36 +51,51 @@ const (
 	resourcesSyncInterval = time.Hour * 1
 )
 
+// loadConfig loads a config in caddy, tests replace it to avoid starting servers.
+var loadConfig = caddy.Load
+
 // Action is an interface for ingress actions.
 type Action interface {
 	handle(c *CaddyController) error
 }
 
 // Informer defines the required SharedIndexInformers that interact with the API server.
+// Ingress, Service, TLS secret, endpoint slice and ConfigMap informers are started for each watched namespace, see namespaceInformers.
 type Informer struct {
-	Ingress   cache.SharedIndexInformer
-	ConfigMap cache.SharedIndexInformer
-	TLSSecret cache.SharedIndexInformer
+	IngressClass cache.SharedIndexInformer
+	ConfigMap    cache.SharedIndexInformer
+	Namespace    cache.SharedIndexInformer
+	History      cache.SharedIndexInformer
+
+	// report whether the event handlers received the initial list of resources
+	IngressClassHandlerSynced cache.InformerSynced
+	ConfigMapHandlerSynced    cache.InformerSynced
 }
 
 // InformerFactory contains shared informer factory
-// We need to type of factory:
-// - One used to watch ConfigMap and Secret resources
-// - Another one for Ingress resources in the selected namespace
+// We need three types of factory:
+// - One used to watch ConfigMap resources in the config namespace
+// - One restricted to the config history Secret, the config namespace also holds certificates
+// - Another one for cluster-scoped resources such as IngressClass and Namespace
+// Ingresses, Services, Secrets, EndpointSlices and served ConfigMaps are watched with a factory per watched namespace.
 type InformerFactory struct {
-	ConfigNamespace  informers.SharedInformerFactory
-	WatchedNamespace informers.SharedInformerFactory
+	ConfigNamespace informers.SharedInformerFactory
+	History         informers.SharedInformerFactory
+	Cluster         informers.SharedInformerFactory
 }
 
+// Converter generates a caddy config from the store.
+// When the returned error only contains converter.IngressError, the config can still be loaded
+// and serves the last good routes of the failing ingresses.
 type Converter interface {
-	ConvertToCaddyConfig(store *store.Store) (any, error)
+	ConvertToCaddyConfig(store *store.Store, lastGoodRoutes converter.IngressRoutes) (any, error)
 }
 
 // CaddyController represents a caddy ingress controller.
 type CaddyController struct {
 	resourceStore *store.Store
 
-	kubeClient *kubernetes.Clientset
+	kubeClient kubernetes.Interface
 
 	logger *zap.SugaredLogger
 
@@ -81,17 +108,80 @@ type CaddyController struct {
 	// informer contains the cache Informers
 	informers *Informer
 
+	// name of the ConfigMap with global options, in the config namespace
+	configMapName string
+
+	// informers of the watched namespaces, by namespace
+	namespaces map[string]*namespaceInformers
+
+	// namespaces to watch, all of them if empty, restricted to the ones matching namespaceSelector
+	watchNamespaces   []string
+	namespaceSelector labels.Selector
+
+	// label selector of the watched ingresses
+	ingressSelector string
+
+	// whether TLS secrets are watched, only when an ingress needs them
+	watchingTLSSecrets bool
+
+	// whether endpoint slices are watched, only when an ingress routes to pods
+	watchingEndpointSlices bool
+
+	// ConfigMaps served by Resource backends, watched one by one by namespace/name
+	resourceConfigMaps map[string]*resourceConfigMapWatch
+
+	// ConfigMaps written in the resource folder, by namespace/name
+	resourceFiles map[string]*apiv1.ConfigMap
+
+	// number of times a failed action is retried before being dropped
+	maxRetries int
+
+	// decides which ingresses are controlled by us
+	ingressClasses k8s.IngressClassMatcher
+
+	// annotation defaults of the ingress classes controlled by us, by class name
+	classParameters map[string]map[string]string
+
+	// informers of the ConfigMaps holding ingress class parameters, by namespace
+	classParametersInformers map[string]cache.SharedIndexInformer
+
 	// save last applied caddy config
 	lastAppliedConfig []byte
 
+	// routes of each ingress in the last generated config that caddy accepted, they are
+	// served in place of the routes of an ingress that fails to convert
+	lastGoodRoutes converter.IngressRoutes
+
+	// coalesces reloads of the caddy config
+	reloads *reloadDebouncer
+
+	// applied configs, persisted in a ConfigMap by the leader
+	history         *configHistory
+	historyName     string
+	rollbackPin     []byte
+	handledRollback string
+
+	// last action that changed the store since the last reload
+	reloadTrigger Action
+
+	// records events about resources that cannot be served
+	events *eventRecorder
+
 	converter Converter
 
+	// leader lease used to elect the instance publishing ingress statuses
+	leaderElectionNamespace string
+	leaderElectionID        string
+	leaderIdentity          string
+	isLeader                atomic.Bool
+	leaderElectionDone      chan struct{}
+
 	stopChan chan struct{}
 }
 
 func NewCaddyController(
 	logger *zap.SugaredLogger,
-	kubeClient *kubernetes.Clientset,
+	kubeClient kubernetes.Interface,
 	opts store.Options,
 	converter Converter,
 	stopChan chan struct{},
@@ -101,9 +191,26 @@ func NewCaddyController(
 		kubeClient: kubeClient,
 		converter:  converter,
 		stopChan:   stopChan,
-		syncQueue:  workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[Action]()),
+		syncQueue: workqueue.NewTypedRateLimitingQueueWithConfig(
+			workqueue.DefaultTypedControllerRateLimiter[Action](),
+			workqueue.TypedRateLimitingQueueConfig[Action]{Name: "actions"},
+		),
 		informers:  &Informer{},
 		factories:  &InformerFactory{},
+		namespaces: map[string]*namespaceInformers{},
+		events:     newEventRecorder(kubeClient),
+		maxRetries: opts.MaxRetries,
+
+		classParameters:          map[string]map[string]string{},
+		classParametersInformers: map[string]cache.SharedIndexInformer{},
+		resourceConfigMaps:       map[string]*resourceConfigMapWatch{},
+		resourceFiles:            map[string]*apiv1.ConfigMap{},
+
+		history:     newConfigHistory(opts.ConfigHistorySize),
+		historyName: opts.ConfigHistoryName,
+
+		leaderElectionID:   opts.LeaderElectionID,
+		leaderElectionDone: make(chan struct{}),
 	}
 
 	podInfo, err := k8s.GetPodDetails(logger, kubeClient)
@@ -120,29 +227,63 @@ func NewCaddyController(
 		logger.Fatalf("Must set a namespace for -config-map when running outside a cluster: %s", opts.ConfigMapName)
 	}
 
+	// The leader lease lives next to the global ConfigMap
+	controller.leaderElectionNamespace = configNamespace
+	if podInfo != nil {
+		controller.leaderIdentity = podInfo.Name
+	} else {
+		hostname, _ := os.Hostname()
+		controller.leaderIdentity = hostname + "_" + uuid.New().String()
+	}
+
 	// Create informer factories
 	controller.factories.ConfigNamespace = informers.NewSharedInformerFactoryWithOptions(
 		kubeClient,
 		resourcesSyncInterval,
 		informers.WithNamespace(configNamespace),
 	)
-	controller.factories.WatchedNamespace = informers.NewSharedInformerFactoryWithOptions(
-		kubeClient,
-		resourcesSyncInterval,
-		informers.WithNamespace(opts.WatchNamespace),
-	)
-
-	// Watch ingress resources in selected namespaces
-	ingressParams := k8s.IngressParams{
-		InformerFactory:   controller.factories.WatchedNamespace,
+	controller.factories.Cluster = informers.NewSharedInformerFactory(kubeClient, resourcesSyncInterval)
+
+	// Watch ingress classes to know which ingresses are controlled by us
+	controller.informers.IngressClass, controller.informers.IngressClassHandlerSynced = k8s.WatchIngressClasses(k8s.IngressClassParams{
+		InformerFactory: controller.factories.Cluster,
+	}, k8s.IngressClassHandlers{
+		AddFunc:    controller.onIngressClassAdded,
+		UpdateFunc: controller.onIngressClassUpdated,
+		DeleteFunc: controller.onIngressClassDeleted,
+	})
+	controller.ingressClasses = k8s.IngressClassMatcher{
 		ClassName:         opts.ClassName,
 		ClassNameRequired: opts.ClassNameRequired,
+		ControllerName:    opts.ControllerName,
+		Lister:            controller.factories.Cluster.Networking().V1().IngressClasses().Lister(),
+	}
+
+	controller.configMapName = configMapName
+	if _, err := labels.Parse(opts.IngressLabelSelector); err != nil {
+		logger.Fatalf("Invalid -ingress-label-selector %q: %v", opts.IngressLabelSelector, err)
+	}
+	if opts.DefaultBackendService != "" {
+		if _, _, err := store.ParseServiceBackend(opts.DefaultBackendService); err != nil {
+			logger.Fatalf("Invalid -default-backend-service: %v", err)
+		}
+	}
+
+	// Watch namespaces matching the selector, ingresses are watched in each of them once the controller runs
+	controller.watchNamespaces = opts.WatchNamespaces
+	controller.namespaceSelector, err = labels.Parse(opts.NamespaceSelector)
+	if err != nil {
+		logger.Fatalf("Invalid -namespace-selector %q: %v", opts.NamespaceSelector, err)
+	}
+	if !controller.namespaceSelector.Empty() {
+		controller.informers.Namespace, _ = k8s.WatchNamespaces(k8s.NamespaceParams{
+			InformerFactory: controller.factories.Cluster,
+		}, k8s.NamespaceHandlers{
+			AddFunc:    controller.onNamespaceAdded,
+			UpdateFunc: controller.onNamespaceUpdated,
+			DeleteFunc: controller.onNamespaceDeleted,
+		})
 	}
-	controller.informers.Ingress = k8s.WatchIngresses(ingressParams, k8s.IngressHandlers{
-		AddFunc:    controller.onIngressAdded,
-		UpdateFunc: controller.onIngressUpdated,
-		DeleteFunc: controller.onIngressDeleted,
-	})
 
 	// Watch Configmap in the pod's namespace for global options
 	cmOptionsParams := k8s.ConfigMapParams{
@@ -150,12 +291,36 @@ func NewCaddyController(
 		InformerFactory: controller.factories.ConfigNamespace,
 		ConfigMapName:   configMapName,
 	}
-	controller.informers.ConfigMap = k8s.WatchConfigMaps(cmOptionsParams, k8s.ConfigMapHandlers{
+	controller.informers.ConfigMap, controller.informers.ConfigMapHandlerSynced = k8s.WatchConfigMaps(cmOptionsParams, k8s.ConfigMapHandlers{
 		AddFunc:    controller.onConfigMapAdded,
 		UpdateFunc: controller.onConfigMapUpdated,
 		DeleteFunc: controller.onConfigMapDeleted,
 	})
 
+	// Watch the config history Secret for changes made by the leader and rollback requests
+	if opts.ConfigHistorySize > 0 {
+		controller.factories.History = informers.NewSharedInformerFactoryWithOptions(
+			kubeClient,
+			resourcesSyncInterval,
+			informers.WithNamespace(configNamespace),
+			informers.WithTweakListOptions(func(o *metav1.ListOptions) {
+				o.FieldSelector = fields.OneTermEqualSelector("metadata.name", opts.ConfigHistoryName).String()
+			}),
+		)
+		controller.informers.History = k8s.WatchSecret(k8s.SecretParams{
+			InformerFactory: controller.factories.History,
+			SecretName:      opts.ConfigHistoryName,
+		}, k8s.SecretHandlers{
+			AddFunc:    controller.onHistoryChanged,
+			UpdateFunc: func(_, new *apiv1.Secret) { controller.onHistoryChanged(new) },
+			DeleteFunc: func(*apiv1.Secret) {},
+		})
+	}
+
+	controller.reloads = newReloadDebouncer(opts.ReloadDebounce, opts.ReloadMaxDelay, func() {
+		controller.syncQueue.Add(ReloadAction{})
+	})
+
 	// Create resource store
 	controller.resourceStore = store.NewStore(opts, configNamespace, podInfo)
 
@@ -164,14 +329,30 @@ func NewCaddyController(
 
 // Shutdown stops the caddy controller.
 func (c *CaddyController) Shutdown() error {
-	// remove this ingress controller's ip from ingress resources.
-	c.updateIngStatuses([]networkingv1.IngressLoadBalancerIngress{{}}, c.resourceStore.Ingresses)
+	// wait for the leader lease to be released so that another replica can take over quickly.
+	select {
+	case <-c.leaderElectionDone:
+	case <-time.After(leaderRenewDeadline):
+		c.logger.Warn("timed out waiting for the leader lease to be released")
+	}
+
+	// remove this ingress controller's ip from ingress resources, only if no other replica
+	// is left to serve them. Otherwise, the new leader keeps the addresses up to date.
+	hasPeers, err := k8s.HasRunningPeers(c.resourceStore.CurrentPod, c.kubeClient)
+	if err != nil {
+		c.logger.Warnf("could not list other ingress controller replicas, keeping ingress statuses: %v", err)
+	} else if !hasPeers {
+		if err := c.updateIngStatuses([]networkingv1.IngressLoadBalancerIngress{{}}, c.resourceStore.Ingresses); err != nil {
+			c.logger.Warnf("could not clear ingress statuses: %v", err)
+		}
+	}
 
 	if err := caddy.Stop(); err != nil {
 		c.logger.Error("failed to stop caddy server", zap.Error(err))
 		return err
 	}
 	certmagic.CleanUpOwnLocks(context.TODO(), c.logger.Desugar())
+	c.events.Shutdown()
 	return nil
 }
 
@@ -180,22 +361,20 @@ func (c *CaddyController) Run() {
 	defer runtime.HandleCrash()
 	defer c.syncQueue.ShutDown()
 
-	// start informers where we listen to new / updated resources
-	go c.informers.ConfigMap.Run(c.stopChan)
-	go c.informers.Ingress.Run(c.stopChan)
-
-	// wait for all involved caches to be synced before processing items
-	// from the queue
-	if !cache.WaitForCacheSync(c.stopChan,
-		c.informers.ConfigMap.HasSynced,
-		c.informers.Ingress.HasSynced,
-	) {
-		runtime.HandleError(fmt.Errorf("timed out waiting for caches to sync"))
-	}
+	c.syncInitialState()
 
 	// start processing events for syncing ingress resources
+	go c.reloads.Run(c.stopChan)
 	go wait.Until(c.runWorker, time.Second, c.stopChan)
 
+	// campaign for the leader lease, only the leader publishes ingress statuses
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-c.stopChan
+		cancel()
+	}()
+	go c.runLeaderElection(ctx, c.leaderElectionNamespace, c.leaderElectionID, c.leaderIdentity)
+
 	// start ingress status syncher and run every syncInterval
 	go wait.Until(c.dispatchSync, syncInterval, c.stopChan)
 
@@ -204,8 +383,7 @@ func (c *CaddyController) Run() {
 	c.logger.Info("stopping ingress controller")
 
 	var exitCode int
-	err := c.Shutdown()
-	if err != nil {
+	if err := c.Shutdown(); err != nil {
 		c.logger.Error("could not shutdown ingress controller properly, " + err.Error())
 		exitCode = 1
 	}
@@ -213,6 +391,64 @@ func (c *CaddyController) Run() {
 	os.Exit(exitCode)
 }
 
+// syncInitialState starts the informers, waits for their caches and loads the initial state
+// of the cluster in caddy with a single reload.
+func (c *CaddyController) syncInitialState() {
+	// start informers where we listen to new / updated resources
+	go c.informers.ConfigMap.Run(c.stopChan)
+	go c.informers.IngressClass.Run(c.stopChan)
+
+	synced := []cache.InformerSynced{
+		c.informers.ConfigMap.HasSynced,
+		c.informers.IngressClass.HasSynced,
+		c.informers.ConfigMapHandlerSynced,
+		c.informers.IngressClassHandlerSynced,
+	}
+	if c.informers.History != nil {
+		go c.informers.History.Run(c.stopChan)
+		synced = append(synced, c.informers.History.HasSynced)
+	}
+
+	// the ingress label selector may be set in the ConfigMap, read it before handling ingresses
+	if cache.WaitForCacheSync(c.stopChan, c.informers.ConfigMap.HasSynced) {
+		c.ingressSelector = c.ingressSelectorFor(c.cachedConfigMapOptions())
+	}
+
+	namespaces, err := c.initialNamespaces()
+	if err != nil {
+		runtime.HandleError(err)
+	}
+	for _, namespace := range namespaces {
+		ns, err := c.watchNamespace(namespace)
+		if err != nil {
+			runtime.HandleError(err)
+		}
+		synced = append(synced, ns.Ingress.HasSynced, ns.IngressHandlerSynced, ns.Service.HasSynced)
+	}
+
+	// wait for all involved caches to be synced, and their initial state to be queued,
+	// before processing items from the queue
+	if !cache.WaitForCacheSync(c.stopChan, synced...) {
+		runtime.HandleError(fmt.Errorf("timed out waiting for caches to sync"))
+	} else {
+		health.SetCachesSynced()
+	}
+
+	// handle the initial state of the cluster and load it with a single reload
+	c.loadHistory()
+	for c.syncQueue.Len() > 0 {
+		action, _ := c.syncQueue.Get()
+		c.handleAction(action)
+		c.syncQueue.Done(action)
+	}
+	c.reload(nil)
+
+	// serve the last known good config while the generated one is rejected
+	if c.lastAppliedConfig == nil {
+		c.restoreLastKnownGood()
+	}
+}
+
 // runWorker processes items in the event queue.
 func (c *CaddyController) runWorker() {
 	for c.processNextItem() {
@@ -232,51 +468,235 @@ func (c *CaddyController) processNextItem() bool {
 	// parallel.
 	defer c.syncQueue.Done(action)
 
-	// Invoke the method containing the business logic
+	health.WorkerBusy()
+	defer health.WorkerIdle()
+
+	if !c.handleAction(action) {
+		return true
+	}
+
+	// The store is up to date, the config is generated and loaded once changes settle down
+	if _, ok := action.(ReloadAction); ok {
+		c.reload(c.reloadTrigger)
+	} else {
+		c.reloadTrigger = action
+		c.reloads.Request()
+	}
+
+	return true
+}
+
+// handleAction invokes the method containing the business logic of an action.
+// It returns false if the action failed.
+func (c *CaddyController) handleAction(action Action) bool {
 	err := action.handle(c)
 	if err != nil {
 		c.handleErr(err, action)
-		return true
+		return false
 	}
 
-	err = c.reloadCaddy()
+	// Stop tracking retries of the action, a failed reload is retried separately
+	if _, ok := action.(ReloadAction); !ok {
+		c.syncQueue.Forget(action)
+	}
+	return true
+}
+
+// reload generates and loads the caddy config, a failed reload is retried.
+// trigger is the last action that changed the store, if any.
+func (c *CaddyController) reload(trigger Action) {
+	err := c.reloadCaddy(trigger)
 	if err != nil {
-		c.logger.Error("could not reload caddy: " + err.Error())
-		return true
+		c.handleErr(fmt.Errorf("could not reload caddy: %w", err), ReloadAction{})
+		return
 	}
+	c.syncQueue.Forget(ReloadAction{})
+}
 
-	return true
+// handleErr requeues failed actions with the queue rate limiter, until they
+// failed maxRetries times. Dropped actions are reported with an event.
+func (c *CaddyController) handleErr(err error, action Action) {
+	if c.syncQueue.NumRequeues(action) < c.maxRetries {
+		c.logger.Warnf("%s failed, retrying: %v", actionName(action), err)
+		c.syncQueue.AddRateLimited(action)
+		return
+	}
+
+	c.syncQueue.Forget(action)
+	c.logger.Errorf("%s failed %d times, dropping it: %v", actionName(action), c.maxRetries+1, err)
+	c.events.Warning(c.eventObject(action), reasonRetriesExhausted, err.Error())
+	metrics.ActionsDroppedTotal.WithLabelValues(actionName(action)).Inc()
+}
+
+// latestResource returns the current version of obj from the informer cache.
+// Actions may be retried after their resource was updated or deleted, in that case the
+// cached version must be used, or the action skipped if the resource is gone.
+// A nil informer means the resource is not watched anymore.
+func latestResource[T any](informer cache.SharedIndexInformer, obj T) (T, bool) {
+	if informer == nil {
+		return obj, false
+	}
+
+	item, exists, err := informer.GetStore().Get(obj)
+	if err != nil {
+		return obj, true
+	}
+	if !exists {
+		return obj, false
+	}
+
+	latest, ok := item.(T)
+	return latest, ok
+}
+
+// actionResource returns the resource an action is about, or nil.
+func actionResource(action Action) k8sruntime.Object {
+	switch a := action.(type) {
+	case IngressAddedAction:
+		return a.resource
+	case IngressUpdatedAction:
+		return a.resource
+	case ConfigMapAddedAction:
+		return a.resource
+	case ConfigMapUpdatedAction:
+		return a.resource
+	case SecretAddedAction:
+		return a.resource
+	case SecretUpdatedAction:
+		return a.resource
+	case ServiceAddedAction:
+		return a.resource
+	case ServiceUpdatedAction:
+		return a.resource
+	case ResourceConfigMapAddedAction:
+		return a.resource
+	case ResourceConfigMapUpdatedAction:
+		return a.resource
+	case EndpointSliceAddedAction:
+		return a.resource
+	case EndpointSliceUpdatedAction:
+		return a.resource
+	}
+	return nil
 }
 
-// handleErrs reports errors received from queue actions.
-//
-//goland:noinspection GoUnusedParameter
-func (c *CaddyController) handleErr(err error, action any) {
-	c.logger.Error(err.Error())
+// eventObject returns the resource that triggered an action, or the controller pod
+// when the action is not related to a single resource.
+func (c *CaddyController) eventObject(action Action) k8sruntime.Object {
+	if obj := actionResource(action); obj != nil {
+		return obj
+	}
+
+	if p := c.resourceStore.CurrentPod; p != nil {
+		return &apiv1.Pod{ObjectMeta: metav1.ObjectMeta{Name: p.Name, Namespace: p.Namespace, UID: p.UID}}
+	}
+	return nil
+}
+
+// describeAction returns a human-readable description of what triggered a reload.
+func describeAction(action Action) string {
+	if action == nil {
+		return "initial sync"
+	}
+	if obj, ok := actionResource(action).(metav1.Object); ok {
+		return fmt.Sprintf("%s (%s/%s)", actionName(action), obj.GetNamespace(), obj.GetName())
+	}
+	return actionName(action)
 }
 
-// reloadCaddy generate a caddy config from controller's store
-func (c *CaddyController) reloadCaddy() error {
-	config, err := c.converter.ConvertToCaddyConfig(c.resourceStore)
+// generateConfig generates a caddy config from controller's store, along with the routes it
+// serves for each ingress.
+func (c *CaddyController) generateConfig() ([]byte, converter.IngressRoutes, error) {
+	start := time.Now()
+	config, err := c.converter.ConvertToCaddyConfig(c.resourceStore, c.lastGoodRoutes)
+	metrics.ConversionDuration.Observe(time.Since(start).Seconds())
+	ingErrs, err := converter.SplitIngressErrors(err)
+	metrics.ConversionsTotal.WithLabelValues(metrics.Result(err)).Inc()
 	if err != nil {
-		return err
+		return nil, nil, err
+	}
+	observeManagedResources(c.resourceStore)
+
+	for _, ingErr := range ingErrs {
+		metrics.IngressConversionErrorsTotal.WithLabelValues(ingErr.Plugin).Inc()
+		c.logger.Errorf("could not convert ingress, keeping its previous routes if any: %v", ingErr)
+		// the controller-wide default backend is not an actual ingress, report it on the controller pod
+		var obj k8sruntime.Object = ingErr.Ingress
+		if ingErr.Ingress.UID == "" {
+			obj = c.eventObject(nil)
+		}
+		c.events.Warning(obj, reasonConversionFailed, fmt.Sprintf(
+			"plugin %s failed for host %q and path %q: %v", ingErr.Plugin, ingErr.Host, ingErr.Path, ingErr.Err,
+		))
 	}
 
 	j, err := json.Marshal(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	metrics.ConfigSize.Set(float64(len(j)))
+
+	var routes converter.IngressRoutes
+	if cfg, ok := config.(*converter.Config); ok {
+		routes = cfg.IngressRoutes
+	}
+	return j, routes, nil
+}
+
+// reloadCaddy generate a caddy config from controller's store and loads it
+func (c *CaddyController) reloadCaddy(action Action) error {
+	j, routes, err := c.generateConfig()
 	if err != nil {
 		return err
 	}
 
 	if bytes.Equal(c.lastAppliedConfig, j) {
 		c.logger.Debug("caddy config did not change, skipping reload")
+		c.lastGoodRoutes = routes
+		health.SetSyncedConfigLoaded()
+		return nil
+	}
+
+	// A rollback pins the restored config until the generated config changes
+	if c.rollbackPin != nil {
+		if bytes.Equal(c.rollbackPin, j) {
+			c.logger.Debug("caddy config is pinned by a rollback, skipping reload")
+			return nil
+		}
+		c.rollbackPin = nil
+	}
+
+	if c.history.IsRejected(j, c.maxRetries) {
+		c.logger.Warn("caddy config was already rejected, skipping reload")
 		return nil
 	}
 
 	c.logger.Debug("reloading caddy with config", string(j))
-	err = caddy.Load(j, false)
+	start := time.Now()
+	err = loadConfig(j, false)
+	metrics.ReloadDuration.Observe(time.Since(start).Seconds())
 	if err != nil {
+		metrics.ReloadsTotal.WithLabelValues("failure").Inc()
+		c.history.RecordFailure(j)
+		c.events.Warning(c.eventObject(action), reasonReloadFailed, fmt.Sprintf("caddy rejected the generated config: %v", err))
 		return fmt.Errorf("could not reload caddy config %v", err.Error())
 	}
-	c.lastAppliedConfig = j
+	metrics.ReloadsTotal.WithLabelValues("success").Inc()
+	c.logger.Infof("caddy config reloaded in %v", time.Since(start))
+	c.setAppliedConfig(j)
+	c.lastGoodRoutes = routes
+	health.SetSyncedConfigLoaded()
+
+	rev := c.history.Add(j, describeAction(action))
+	c.logger.Infof("applied config revision %d, triggered by %s", rev.Revision, rev.Trigger)
+	c.persistHistory()
 	return nil
 }
+
+// setAppliedConfig records the config caddy is running with.
+func (c *CaddyController) setAppliedConfig(config []byte) {
+	c.lastAppliedConfig = config
+	if err := debug.SetAppliedConfig(config); err != nil {
+		c.logger.Warnf("could not snapshot applied config: %v", err)
+	}
+}
@@ -0,0 +1,228 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+	"github.com/caddyserver/ingress/internal/metrics"
+	"github.com/caddyserver/ingress/pkg/converter"
+	"github.com/caddyserver/ingress/pkg/store"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// fakeConverter generates a config serving the given routes and remembers the last good
+// routes it was called with.
+type fakeConverter struct {
+	routes         converter.IngressRoutes
+	lastGoodRoutes converter.IngressRoutes
+}
+
+func (f *fakeConverter) ConvertToCaddyConfig(_ *store.Store, lastGoodRoutes converter.IngressRoutes) (any, error) {
+	f.lastGoodRoutes = lastGoodRoutes
+	cfg := converter.NewConfig()
+	cfg.IngressRoutes = f.routes
+	cfg.GetHTTPServer().Routes = caddyhttp.RouteList{}
+	for _, r := range f.routes {
+		cfg.GetHTTPServer().Routes = append(cfg.GetHTTPServer().Routes, r...)
+	}
+	return cfg, nil
+}
+
+// stubLoadConfig replaces the caddy config loader for the duration of the test.
+// It returns the configs that were loaded.
+func stubLoadConfig(t *testing.T, load func(config []byte) error) *[][]byte {
+	var loaded [][]byte
+	previous := loadConfig
+	loadConfig = func(config []byte, _ bool) error {
+		if err := load(config); err != nil {
+			return err
+		}
+		loaded = append(loaded, config)
+		return nil
+	}
+	t.Cleanup(func() { loadConfig = previous })
+	return &loaded
+}
+
+func TestReloadCommitsRoutesOfAcceptedConfig(t *testing.T) {
+	events, _, _ := newFakeEventRecorder(time.Now())
+	conv := &fakeConverter{}
+	c := &CaddyController{
+		logger:        zap.NewNop().Sugar(),
+		events:        events,
+		converter:     conv,
+		history:       newConfigHistory(0),
+		maxRetries:    3,
+		resourceStore: store.NewStore(store.Options{}, "caddy-system", nil),
+	}
+
+	route := func(host string) caddyhttp.RouteList {
+		return caddyhttp.RouteList{{Group: host}}
+	}
+
+	var rejected error
+	loaded := stubLoadConfig(t, func([]byte) error { return rejected })
+
+	// routes of a config rejected by caddy are not kept
+	conv.routes = converter.IngressRoutes{types.UID("a"): route("a.example.com")}
+	rejected = errors.New("rejected")
+	require.Error(t, c.reloadCaddy(nil))
+	require.Nil(t, c.lastGoodRoutes)
+
+	// routes of an accepted config are used in the next conversion
+	rejected = nil
+	require.NoError(t, c.reloadCaddy(nil))
+	require.Len(t, *loaded, 1)
+	require.Equal(t, conv.routes, c.lastGoodRoutes)
+
+	accepted := conv.routes
+	conv.routes = converter.IngressRoutes{types.UID("a"): route("b.example.com")}
+	rejected = errors.New("rejected")
+	require.Error(t, c.reloadCaddy(nil))
+	require.Equal(t, accepted, conv.lastGoodRoutes)
+	require.Equal(t, accepted, c.lastGoodRoutes)
+}
+
+func TestInitialSyncLoadsConfigOnce(t *testing.T) {
+	client := fake.NewClientset()
+	className := "caddy"
+	for i := range 50 {
+		require.NoError(t, client.Tracker().Add(&networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("ing-%d", i), Namespace: "default", UID: types.UID(fmt.Sprint(i))},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: &className,
+				DefaultBackend: &networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{
+					Name: "svc",
+					Port: networkingv1.ServiceBackendPort{Number: 80},
+				}},
+			},
+		}))
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	c := NewCaddyController(zap.NewNop().Sugar(), client, store.Options{
+		ConfigMapName:  "caddy-system/caddy-global-options",
+		ClassName:      className,
+		MaxRetries:     3,
+		ReloadDebounce: time.Second,
+		ReloadMaxDelay: 5 * time.Second,
+	}, &fakeConverter{}, stopCh)
+	defer c.syncQueue.ShutDown()
+	defer c.events.Shutdown()
+
+	loaded := stubLoadConfig(t, func([]byte) error { return nil })
+	c.syncInitialState()
+
+	// all ingresses are handled before a single config is loaded
+	require.Len(t, c.resourceStore.Ingresses, 50)
+	require.Zero(t, c.syncQueue.Len())
+	require.Len(t, *loaded, 1)
+}
+
+func TestHandleErrDropsActionAfterMaxRetries(t *testing.T) {
+	events, fakeRecorder, _ := newFakeEventRecorder(time.Now())
+	queue := workqueue.NewTypedRateLimitingQueue(
+		workqueue.NewTypedItemExponentialFailureRateLimiter[Action](time.Millisecond, time.Millisecond),
+	)
+	defer queue.ShutDown()
+
+	c := &CaddyController{
+		logger:        zap.NewNop().Sugar(),
+		syncQueue:     queue,
+		events:        events,
+		maxRetries:    2,
+		resourceStore: &store.Store{},
+	}
+
+	ing := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "ing", Namespace: "default", UID: "ing"}}
+	action := IngressAddedAction{resource: ing}
+	dropped := metrics.ActionsDroppedTotal.WithLabelValues("IngressAddedAction")
+	droppedBefore := testutil.ToFloat64(dropped)
+	err := errors.New("conversion failed")
+
+	// the action is requeued until it failed maxRetries times
+	for i := 1; i <= c.maxRetries; i++ {
+		c.handleErr(err, action)
+		require.Equal(t, i, queue.NumRequeues(action))
+
+		item, _ := queue.Get()
+		require.Equal(t, action, item)
+		queue.Done(item)
+		require.Empty(t, recordedEvents(fakeRecorder))
+	}
+
+	// then it is forgotten, dropped and reported
+	c.handleErr(err, action)
+	require.Equal(t, 0, queue.NumRequeues(action))
+	require.Equal(t, 0, queue.Len())
+	require.Equal(t, []string{"Warning RetriesExhausted conversion failed"}, recordedEvents(fakeRecorder))
+	require.Equal(t, droppedBefore+1, testutil.ToFloat64(dropped))
+}
+
+func TestShutdownClearsIngressStatusesWithoutPeers(t *testing.T) {
+	current := &store.PodInfo{Name: "caddy-0", Namespace: "caddy-system", Labels: map[string]string{"app": "caddy"}}
+	peer := &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "caddy-1", Namespace: "caddy-system", Labels: map[string]string{"app": "caddy"}},
+		Status: apiv1.PodStatus{
+			Phase:      apiv1.PodRunning,
+			Conditions: []apiv1.PodCondition{{Type: apiv1.PodReady, Status: apiv1.ConditionTrue}},
+		},
+	}
+
+	testCases := []struct {
+		desc           string
+		peers          []*apiv1.Pod
+		expectedStatus []networkingv1.IngressLoadBalancerIngress
+	}{
+		{
+			desc:           "last replica clears the statuses",
+			expectedStatus: []networkingv1.IngressLoadBalancerIngress{{}},
+		},
+		{
+			desc:           "running peer keeps the statuses",
+			peers:          []*apiv1.Pod{peer},
+			expectedStatus: []networkingv1.IngressLoadBalancerIngress{{IP: "10.0.0.1"}},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			ing := &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Name: "ing", Namespace: "default"},
+				Status: networkingv1.IngressStatus{LoadBalancer: networkingv1.IngressLoadBalancerStatus{
+					Ingress: []networkingv1.IngressLoadBalancerIngress{{IP: "10.0.0.1"}},
+				}},
+			}
+			client := fake.NewClientset(ing)
+			for _, pod := range tC.peers {
+				require.NoError(t, client.Tracker().Add(pod))
+			}
+
+			c := &CaddyController{
+				logger:             zap.NewNop().Sugar(),
+				kubeClient:         client,
+				resourceStore:      &store.Store{CurrentPod: current, Ingresses: []*networkingv1.Ingress{ing}},
+				leaderElectionDone: make(chan struct{}),
+			}
+			close(c.leaderElectionDone)
+			require.NoError(t, c.Shutdown())
+
+			updated, err := client.NetworkingV1().Ingresses("default").Get(context.Background(), "ing", metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, tC.expectedStatus, updated.Status.LoadBalancer.Ingress)
+		})
+	}
+}
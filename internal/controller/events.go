@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"sync"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
+)
+
+const (
+	eventComponent = "caddy-ingress-controller"
+
+	// the same event is not recorded twice for an object within this interval
+	eventDedupInterval = time.Hour * 1
+)
+
+// Event reasons
+const (
+	reasonConversionFailed    = "ConversionFailed"
+	reasonReloadFailed        = "ReloadFailed"
+	reasonTLSSecretMissing    = "TLSSecretMissing"
+	reasonTLSSecretInvalid    = "TLSSecretInvalid"
+	reasonInvalidConfigMap    = "InvalidConfigMap"
+	reasonRetriesExhausted    = "RetriesExhausted"
+	reasonRollbackFailed      = "RollbackFailed"
+	reasonInvalidIngressClass = "InvalidIngressClass"
+
+	reasonDefaultBackendNotWatched = "DefaultBackendNotWatched"
+)
+
+type eventKey struct {
+	uid     types.UID
+	name    string
+	reason  string
+	message string
+}
+
+// eventRecorder records Kubernetes events about resources managed by the controller.
+// Errors are re-evaluated on each sync, so identical events are deduplicated for eventDedupInterval.
+type eventRecorder struct {
+	broadcaster record.EventBroadcaster
+	recorder    record.EventRecorder
+	clock       clock.PassiveClock
+
+	mu       sync.Mutex
+	recorded map[eventKey]time.Time
+}
+
+func newEventRecorder(kubeClient kubernetes.Interface) *eventRecorder {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+
+	return &eventRecorder{
+		broadcaster: broadcaster,
+		recorder:    broadcaster.NewRecorder(scheme.Scheme, apiv1.EventSource{Component: eventComponent}),
+		clock:       clock.RealClock{},
+		recorded:    map[eventKey]time.Time{},
+	}
+}
+
+// Warning records a warning event for obj unless it was recently recorded.
+func (r *eventRecorder) Warning(obj runtime.Object, reason, message string) {
+	if r == nil || obj == nil {
+		return
+	}
+
+	meta, ok := obj.(metav1.Object)
+	if !ok {
+		return
+	}
+
+	key := eventKey{
+		uid:     meta.GetUID(),
+		name:    meta.GetNamespace() + "/" + meta.GetName(),
+		reason:  reason,
+		message: message,
+	}
+
+	now := r.clock.Now()
+	r.mu.Lock()
+	for k, t := range r.recorded {
+		if now.Sub(t) > eventDedupInterval {
+			delete(r.recorded, k)
+		}
+	}
+	_, found := r.recorded[key]
+	if !found {
+		r.recorded[key] = now
+	}
+	r.mu.Unlock()
+
+	if !found {
+		r.recorder.Event(obj, apiv1.EventTypeWarning, reason, message)
+	}
+}
+
+// Shutdown stops sending events to the API server.
+func (r *eventRecorder) Shutdown() {
+	if r != nil && r.broadcaster != nil {
+		r.broadcaster.Shutdown()
+	}
+}
@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+// newFakeEventRecorder returns an event recorder keeping events in memory.
+func newFakeEventRecorder(now time.Time) (*eventRecorder, *record.FakeRecorder, *clocktesting.FakePassiveClock) {
+	fakeRecorder := record.NewFakeRecorder(100)
+	clock := clocktesting.NewFakePassiveClock(now)
+	return &eventRecorder{recorder: fakeRecorder, clock: clock, recorded: map[eventKey]time.Time{}}, fakeRecorder, clock
+}
+
+// recordedEvents returns the events recorded since the last call.
+func recordedEvents(r *record.FakeRecorder) []string {
+	var events []string
+	for {
+		select {
+		case e := <-r.Events:
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+func TestEventRecorderDeduplicatesEvents(t *testing.T) {
+	r, fakeRecorder, clock := newFakeEventRecorder(time.Now())
+	ing := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "ing", Namespace: "default", UID: "ing"}}
+
+	r.Warning(ing, reasonConversionFailed, "invalid path")
+	require.Equal(t, []string{"Warning ConversionFailed invalid path"}, recordedEvents(fakeRecorder))
+
+	// the same event on each sync within the dedup interval
+	for range 10 {
+		clock.SetTime(clock.Now().Add(syncInterval))
+		r.Warning(ing, reasonConversionFailed, "invalid path")
+	}
+	require.Empty(t, recordedEvents(fakeRecorder))
+
+	// other events are recorded
+	r.Warning(ing, reasonConversionFailed, "invalid host")
+	require.Equal(t, []string{"Warning ConversionFailed invalid host"}, recordedEvents(fakeRecorder))
+
+	// the event is recorded again once the dedup interval expired
+	clock.SetTime(clock.Now().Add(eventDedupInterval))
+	r.Warning(ing, reasonConversionFailed, "invalid path")
+	require.Equal(t, []string{"Warning ConversionFailed invalid path"}, recordedEvents(fakeRecorder))
+}
+
+func TestCheckTLSSecrets(t *testing.T) {
+	factory := informers.NewSharedInformerFactory(fake.NewClientset(), 0)
+	secretInformer := factory.Core().V1().Secrets().Informer()
+	require.NoError(t, secretInformer.GetIndexer().Add(&apiv1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "invalid", Namespace: "default"},
+		Type:       apiv1.SecretTypeTLS,
+		Data: map[string][]byte{
+			apiv1.TLSCertKey:       []byte("not a certificate"),
+			apiv1.TLSPrivateKeyKey: []byte("not a key"),
+		},
+	}))
+
+	events, fakeRecorder, _ := newFakeEventRecorder(time.Now())
+	c := &CaddyController{
+		logger: zap.NewNop().Sugar(),
+		events: events,
+		namespaces: map[string]*namespaceInformers{
+			"default": {factory: factory, TLSSecret: secretInformer},
+		},
+	}
+
+	ing := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "ing", Namespace: "default", UID: "ing"},
+		Spec: networkingv1.IngressSpec{TLS: []networkingv1.IngressTLS{
+			{SecretName: "missing"},
+			{SecretName: "invalid"},
+			{Hosts: []string{"on-demand.example.com"}},
+		}},
+	}
+	c.checkTLSSecrets(ing)
+
+	recorded := recordedEvents(fakeRecorder)
+	require.Len(t, recorded, 2)
+	require.Equal(t, "Warning TLSSecretMissing TLS secret missing does not exist", recorded[0])
+	require.Contains(t, recorded[1], "Warning TLSSecretInvalid secret default/invalid does not contain a valid certificate")
+}
@@ -0,0 +1,216 @@
+package controller
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+)
+
+const (
+	// Secret data key listing the revisions kept in history
+	historyRevisionsKey = "revisions"
+
+	// Secret data key prefix holding a gzipped config
+	historyConfigKeyPrefix = "revision-"
+
+	// Secrets cannot exceed 1MiB, keep some room for metadata
+	historyMaxBytes = 900 * 1024
+
+	// annotation set on the history Secret to roll back to a revision
+	rollbackAnnotation = "caddy.ingress.kubernetes.io/rollback"
+
+	// rollbackAnnotation value to roll back to the revision before the current one
+	rollbackPrevious = "previous"
+)
+
+// configRevision is a caddy config that was successfully applied.
+type configRevision struct {
+	Revision  int       `json:"revision"`
+	Trigger   string    `json:"trigger"`
+	AppliedAt time.Time `json:"appliedAt"`
+	Hash      string    `json:"sha256"`
+	Size      int       `json:"size"`
+
+	config     []byte
+	compressed []byte
+}
+
+// configHistory keeps a bounded list of applied configs, oldest first.
+// It also remembers the last config rejected by caddy so that it is not retried forever.
+type configHistory struct {
+	size      int
+	revisions []*configRevision
+
+	rejectedHash string
+	failures     int
+}
+
+func newConfigHistory(size int) *configHistory {
+	return &configHistory{size: size}
+}
+
+func configHash(config []byte) string {
+	sum := sha256.Sum256(config)
+	return hex.EncodeToString(sum[:])
+}
+
+// Add records an applied config as a new revision.
+func (h *configHistory) Add(config []byte, trigger string) *configRevision {
+	rev := &configRevision{
+		Revision:  1,
+		Trigger:   trigger,
+		AppliedAt: time.Now().UTC(),
+		Hash:      configHash(config),
+		Size:      len(config),
+		config:    config,
+	}
+	if current := h.Current(); current != nil {
+		rev.Revision = current.Revision + 1
+	}
+
+	h.revisions = append(h.revisions, rev)
+	if len(h.revisions) > h.size {
+		h.revisions = h.revisions[len(h.revisions)-h.size:]
+	}
+
+	// a config that was applied is not rejected anymore
+	if rev.Hash == h.rejectedHash {
+		h.rejectedHash, h.failures = "", 0
+	}
+	return rev
+}
+
+// Current returns the last applied revision, which is the last known good config.
+func (h *configHistory) Current() *configRevision {
+	if len(h.revisions) == 0 {
+		return nil
+	}
+	return h.revisions[len(h.revisions)-1]
+}
+
+// Get returns the revision with the given number, or nil if it is not in history anymore.
+func (h *configHistory) Get(revision int) *configRevision {
+	for _, rev := range h.revisions {
+		if rev.Revision == revision {
+			return rev
+		}
+	}
+	return nil
+}
+
+// Resolve returns the revision targeted by a rollback annotation value.
+func (h *configHistory) Resolve(target string) (*configRevision, error) {
+	if target == rollbackPrevious {
+		if len(h.revisions) < 2 {
+			return nil, fmt.Errorf("no previous revision in history")
+		}
+		return h.revisions[len(h.revisions)-2], nil
+	}
+
+	revision, err := strconv.Atoi(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid revision %q, expected a number or %q", target, rollbackPrevious)
+	}
+
+	rev := h.Get(revision)
+	if rev == nil {
+		return nil, fmt.Errorf("revision %d is not in history", revision)
+	}
+	return rev, nil
+}
+
+// RecordFailure records that caddy rejected the config and returns how many times
+// in a row the same config was rejected.
+func (h *configHistory) RecordFailure(config []byte) int {
+	hash := configHash(config)
+	if hash != h.rejectedHash {
+		h.rejectedHash, h.failures = hash, 0
+	}
+	h.failures++
+	return h.failures
+}
+
+// IsRejected returns true if the config was rejected more than maxFailures times in a row.
+func (h *configHistory) IsRejected(config []byte, maxFailures int) bool {
+	return h.failures > maxFailures && configHash(config) == h.rejectedHash
+}
+
+// WriteSecret stores the history in the Secret data, dropping the oldest
+// revisions that do not fit in a Secret. Configs hold credentials such as the
+// ACME EAB key, so they are not stored in a ConfigMap.
+func (h *configHistory) WriteSecret(secret *apiv1.Secret) error {
+	for _, rev := range h.revisions {
+		if rev.compressed != nil {
+			continue
+		}
+
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(rev.config); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		rev.compressed = buf.Bytes()
+	}
+
+	// keep the most recent revisions that fit
+	revisions := h.revisions
+	total := 0
+	for i := len(revisions) - 1; i >= 0; i-- {
+		total += len(revisions[i].compressed)
+		if total > historyMaxBytes {
+			revisions = revisions[i+1:]
+			break
+		}
+	}
+
+	index, err := json.Marshal(revisions)
+	if err != nil {
+		return err
+	}
+
+	secret.Data = map[string][]byte{historyRevisionsKey: index}
+	for _, rev := range revisions {
+		secret.Data[historyConfigKeyPrefix+strconv.Itoa(rev.Revision)+".json.gz"] = rev.compressed
+	}
+	return nil
+}
+
+// ReadSecret restores the history from a Secret written by WriteSecret.
+func (h *configHistory) ReadSecret(secret *apiv1.Secret) error {
+	var revisions []*configRevision
+	if err := json.Unmarshal(secret.Data[historyRevisionsKey], &revisions); err != nil {
+		return fmt.Errorf("invalid history index: %w", err)
+	}
+
+	h.revisions = nil
+	for _, rev := range revisions {
+		rev.compressed = secret.Data[historyConfigKeyPrefix+strconv.Itoa(rev.Revision)+".json.gz"]
+
+		zr, err := gzip.NewReader(bytes.NewReader(rev.compressed))
+		if err != nil {
+			return fmt.Errorf("invalid config for revision %d: %w", rev.Revision, err)
+		}
+		rev.config, err = io.ReadAll(zr)
+		if err != nil {
+			return fmt.Errorf("invalid config for revision %d: %w", rev.Revision, err)
+		}
+
+		h.revisions = append(h.revisions, rev)
+	}
+
+	if len(h.revisions) > h.size {
+		h.revisions = h.revisions[len(h.revisions)-h.size:]
+	}
+	return nil
+}
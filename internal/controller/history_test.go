@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+func TestConfigHistoryAdd(t *testing.T) {
+	h := newConfigHistory(2)
+	require.Nil(t, h.Current())
+
+	h.Add([]byte(`{"a":1}`), "initial sync")
+	h.Add([]byte(`{"a":2}`), "IngressAddedAction (default/foo)")
+	h.Add([]byte(`{"a":3}`), "IngressUpdatedAction (default/foo)")
+
+	require.Len(t, h.revisions, 2)
+	require.Equal(t, 3, h.Current().Revision)
+	require.Equal(t, []byte(`{"a":3}`), h.Current().config)
+	require.Nil(t, h.Get(1), "oldest revision should be dropped")
+	require.NotNil(t, h.Get(2))
+}
+
+func TestConfigHistoryResolve(t *testing.T) {
+	h := newConfigHistory(5)
+
+	_, err := h.Resolve(rollbackPrevious)
+	require.Error(t, err)
+
+	h.Add([]byte(`{"a":1}`), "initial sync")
+	h.Add([]byte(`{"a":2}`), "ReloadAction")
+
+	testCases := []struct {
+		name        string
+		target      string
+		expectedRev int
+		expectedErr string
+	}{
+		{name: "previous revision", target: "previous", expectedRev: 1},
+		{name: "revision number", target: "2", expectedRev: 2},
+		{name: "unknown revision", target: "42", expectedErr: "revision 42 is not in history"},
+		{name: "invalid revision", target: "latest", expectedErr: `invalid revision "latest", expected a number or "previous"`},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			rev, err := h.Resolve(tC.target)
+			if tC.expectedErr != "" {
+				require.EqualError(t, err, tC.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tC.expectedRev, rev.Revision)
+		})
+	}
+}
+
+func TestConfigHistoryRejectsFailingConfig(t *testing.T) {
+	h := newConfigHistory(5)
+	bad := []byte(`{"bad":true}`)
+
+	require.Equal(t, 1, h.RecordFailure(bad))
+	require.Equal(t, 2, h.RecordFailure(bad))
+	require.False(t, h.IsRejected(bad, 2))
+
+	require.Equal(t, 3, h.RecordFailure(bad))
+	require.True(t, h.IsRejected(bad, 2))
+	require.False(t, h.IsRejected([]byte(`{"good":true}`), 2), "other configs should still be tried")
+
+	// a different failing config restarts the count
+	require.Equal(t, 1, h.RecordFailure([]byte(`{"other":true}`)))
+	require.False(t, h.IsRejected(bad, 2))
+}
+
+func TestConfigHistorySecretRoundTrip(t *testing.T) {
+	h := newConfigHistory(5)
+	h.Add([]byte(`{"a":1}`), "initial sync")
+	h.Add([]byte(`{"a":2}`), "ReloadAction")
+
+	secret := &apiv1.Secret{}
+	require.NoError(t, h.WriteSecret(secret))
+	require.Contains(t, secret.Data, historyRevisionsKey)
+	require.Len(t, secret.Data, 3)
+
+	restored := newConfigHistory(5)
+	require.NoError(t, restored.ReadSecret(secret))
+	require.Len(t, restored.revisions, 2)
+	require.Equal(t, h.Current().Hash, restored.Current().Hash)
+	require.Equal(t, "ReloadAction", restored.Current().Trigger)
+	require.Equal(t, []byte(`{"a":2}`), restored.Current().config)
+
+	// history keeps numbering revisions after a restore
+	require.Equal(t, 3, restored.Add([]byte(`{"a":3}`), "ReloadAction").Revision)
+}
@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// Lease timings, variables so that tests can shorten them.
+var (
+	// how long a leader keeps the lease without renewing it. This bounds the time
+	// needed for another replica to take over ingress status updates.
+	leaderLeaseDuration = 15 * time.Second
+
+	// how long the leader retries to renew the lease before giving up leadership.
+	leaderRenewDeadline = 10 * time.Second
+
+	// how often candidates try to acquire or renew the lease.
+	leaderRetryPeriod = 2 * time.Second
+)
+
+// IsLeader returns true if this instance currently holds the leader lease.
+func (c *CaddyController) IsLeader() bool {
+	return c.isLeader.Load()
+}
+
+// runLeaderElection campaigns for the leader lease until ctx is cancelled.
+// Only the leader publishes the load balancer addresses in ingress statuses.
+func (c *CaddyController) runLeaderElection(ctx context.Context, namespace, name, identity string) {
+	defer close(c.leaderElectionDone)
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Client: c.kubeClient.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: identity,
+		},
+	}
+
+	// A leader elector returns as soon as it loses the lease, campaign again
+	// until the controller is stopped.
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		le, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+			Lock:            lock,
+			Name:            name,
+			LeaseDuration:   leaderLeaseDuration,
+			RenewDeadline:   leaderRenewDeadline,
+			RetryPeriod:     leaderRetryPeriod,
+			ReleaseOnCancel: true,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(ctx context.Context) {
+					c.logger.Infof("Acquired leader lease %s/%s, publishing ingress statuses", namespace, name)
+					c.isLeader.Store(true)
+					c.dispatchSync()
+					// configs applied before this instance became the leader were not persisted
+					c.syncQueue.Add(PersistHistoryAction{})
+				},
+				OnStoppedLeading: func() {
+					if c.isLeader.Swap(false) {
+						c.logger.Infof("Lost leader lease %s/%s", namespace, name)
+					}
+				},
+				OnNewLeader: func(current string) {
+					if current != identity {
+						c.logger.Infof("Ingress statuses are now published by %s", current)
+					}
+				},
+			},
+		})
+		if err != nil {
+			c.logger.Errorf("could not create leader elector: %v", err)
+			return
+		}
+		le.Run(ctx)
+	}, leaderRetryPeriod)
+}
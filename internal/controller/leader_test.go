@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// shortenLeaderElection speeds up leader elections for the duration of the test.
+func shortenLeaderElection(t *testing.T) {
+	lease, renew, retry := leaderLeaseDuration, leaderRenewDeadline, leaderRetryPeriod
+	leaderLeaseDuration, leaderRenewDeadline, leaderRetryPeriod = time.Second, 500*time.Millisecond, 100*time.Millisecond
+	t.Cleanup(func() {
+		leaderLeaseDuration, leaderRenewDeadline, leaderRetryPeriod = lease, renew, retry
+	})
+}
+
+func newLeaderTestController(client *fake.Clientset) *CaddyController {
+	return &CaddyController{
+		logger:             zap.NewNop().Sugar(),
+		kubeClient:         client,
+		syncQueue:          workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[Action]()),
+		leaderElectionDone: make(chan struct{}),
+	}
+}
+
+func TestLeaderElectionAcquiresAndLosesLease(t *testing.T) {
+	shortenLeaderElection(t)
+
+	client := fake.NewClientset()
+	c := newLeaderTestController(client)
+	defer c.syncQueue.ShutDown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.runLeaderElection(ctx, "caddy-system", "caddy-leader", "caddy-0")
+
+	// the lease is acquired, ingress statuses are synced and the config history is persisted
+	require.Eventually(t, c.IsLeader, 5*time.Second, 10*time.Millisecond)
+	lease, err := client.CoordinationV1().Leases("caddy-system").Get(ctx, "caddy-leader", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "caddy-0", *lease.Spec.HolderIdentity)
+	for _, expected := range []Action{SyncStatusAction{}, PersistHistoryAction{}} {
+		item, _ := c.syncQueue.Get()
+		require.Equal(t, expected, item)
+		c.syncQueue.Done(item)
+	}
+
+	// leadership is lost once the lease cannot be renewed
+	client.PrependReactor("update", "leases", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("api server unavailable")
+	})
+	require.Eventually(t, func() bool { return !c.IsLeader() }, 5*time.Second, 10*time.Millisecond)
+
+	// the campaign stops with the controller
+	cancel()
+	select {
+	case <-c.leaderElectionDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("leader election did not stop")
+	}
+	require.False(t, c.IsLeader())
+}
+
+func TestLeaderElectionWaitsForLeaseHeldByOtherReplica(t *testing.T) {
+	shortenLeaderElection(t)
+
+	holder := "caddy-1"
+	duration := int32(60)
+	client := fake.NewClientset(&coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: "caddy-leader", Namespace: "caddy-system"},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       &holder,
+			LeaseDurationSeconds: &duration,
+			AcquireTime:          &metav1.MicroTime{Time: time.Now()},
+			RenewTime:            &metav1.MicroTime{Time: time.Now()},
+		},
+	})
+	c := newLeaderTestController(client)
+	defer c.syncQueue.ShutDown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go c.runLeaderElection(ctx, "caddy-system", "caddy-leader", "caddy-0")
+
+	// several retry periods pass without taking over the lease
+	time.Sleep(5 * leaderRetryPeriod)
+	require.False(t, c.IsLeader())
+	require.Equal(t, 0, c.syncQueue.Len())
+
+	cancel()
+	<-c.leaderElectionDone
+}
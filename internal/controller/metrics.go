@@ -0,0 +1,31 @@
+package controller
+
+import (
+	"reflect"
+
+	"github.com/caddyserver/ingress/internal/metrics"
+	"github.com/caddyserver/ingress/pkg/store"
+)
+
+// actionName returns the name of an action type to be used as a metric label.
+func actionName(action Action) string {
+	return reflect.TypeOf(action).Name()
+}
+
+// observeManagedResources updates the metrics about the resources in the store.
+func observeManagedResources(s *store.Store) {
+	hosts := map[string]struct{}{}
+	routes := 0
+	for _, ing := range s.Ingresses {
+		for _, rule := range ing.Spec.Rules {
+			hosts[rule.Host] = struct{}{}
+			if rule.HTTP != nil {
+				routes += len(rule.HTTP.Paths)
+			}
+		}
+	}
+
+	metrics.ManagedIngresses.Set(float64(len(s.Ingresses)))
+	metrics.ManagedHosts.Set(float64(len(hosts)))
+	metrics.ManagedRoutes.Set(float64(routes))
+}
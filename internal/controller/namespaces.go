@@ -0,0 +1,235 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/caddyserver/ingress/internal/k8s"
+	"github.com/caddyserver/ingress/pkg/store"
+	apiv1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// namespaceInformers holds the informers of the resources of a watched namespace.
+type namespaceInformers struct {
+	factory informers.SharedInformerFactory
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	Ingress       cache.SharedIndexInformer
+	Service       cache.SharedIndexInformer
+	TLSSecret     cache.SharedIndexInformer
+	EndpointSlice cache.SharedIndexInformer
+
+	// reports whether the event handlers received the initial list of ingresses
+	IngressHandlerSynced cache.InformerSynced
+}
+
+// initialNamespaces returns the namespaces to watch on startup, metav1.NamespaceAll
+// when the controller watches all namespaces.
+func (c *CaddyController) initialNamespaces() ([]string, error) {
+	if c.informers.Namespace == nil {
+		if len(c.watchNamespaces) == 0 {
+			return []string{metav1.NamespaceAll}, nil
+		}
+		return c.watchNamespaces, nil
+	}
+
+	go c.informers.Namespace.Run(c.stopChan)
+	if !cache.WaitForCacheSync(c.stopChan, c.informers.Namespace.HasSynced) {
+		return nil, fmt.Errorf("timed out waiting for namespaces cache to sync")
+	}
+
+	var namespaces []string
+	for _, obj := range c.informers.Namespace.GetStore().List() {
+		if ns := obj.(*apiv1.Namespace); c.isWatchedNamespace(ns) {
+			namespaces = append(namespaces, ns.Name)
+		}
+	}
+	return namespaces, nil
+}
+
+// isWatchedNamespace returns whether the resources of the namespace are watched.
+func (c *CaddyController) isWatchedNamespace(ns *apiv1.Namespace) bool {
+	if len(c.watchNamespaces) > 0 && !slices.Contains(c.watchNamespaces, ns.Name) {
+		return false
+	}
+	return c.namespaceSelector.Matches(labels.Set(ns.Labels))
+}
+
+// namespaceInformersFor returns the informers watching the namespace, or nil if it is not watched.
+func (c *CaddyController) namespaceInformersFor(namespace string) *namespaceInformers {
+	if ns, ok := c.namespaces[metav1.NamespaceAll]; ok {
+		return ns
+	}
+	return c.namespaces[namespace]
+}
+
+// ingressInformer returns the informer of the ingresses of the namespace, or nil if it is not watched.
+func (c *CaddyController) ingressInformer(namespace string) cache.SharedIndexInformer {
+	if ns := c.namespaceInformersFor(namespace); ns != nil {
+		return ns.Ingress
+	}
+	return nil
+}
+
+// tlsSecretInformer returns the informer of the TLS secrets of the namespace, or nil if they are not watched.
+func (c *CaddyController) tlsSecretInformer(namespace string) cache.SharedIndexInformer {
+	if ns := c.namespaceInformersFor(namespace); ns != nil {
+		return ns.TLSSecret
+	}
+	return nil
+}
+
+// serviceInformer returns the informer of the services of the namespace, or nil if it is not watched.
+func (c *CaddyController) serviceInformer(namespace string) cache.SharedIndexInformer {
+	if ns := c.namespaceInformersFor(namespace); ns != nil {
+		return ns.Service
+	}
+	return nil
+}
+
+// endpointSliceInformer returns the informer of the endpoint slices of the namespace, or nil if they are not watched.
+func (c *CaddyController) endpointSliceInformer(namespace string) cache.SharedIndexInformer {
+	if ns := c.namespaceInformersFor(namespace); ns != nil {
+		return ns.EndpointSlice
+	}
+	return nil
+}
+
+// resourceConfigMapInformer returns the informer of a ConfigMap served by Resource backends, or nil if it is not watched.
+func (c *CaddyController) resourceConfigMapInformer(namespace, name string) cache.SharedIndexInformer {
+	if w, ok := c.resourceConfigMaps[namespace+"/"+name]; ok {
+		return w.informer
+	}
+	return nil
+}
+
+// watchNamespace starts the informers of a namespace.
+func (c *CaddyController) watchNamespace(namespace string) (*namespaceInformers, error) {
+	if ns, ok := c.namespaces[namespace]; ok {
+		return ns, nil
+	}
+
+	// informers of a namespace stop with the controller or when the namespace is not watched anymore
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-c.stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ns := &namespaceInformers{
+		factory: informers.NewSharedInformerFactoryWithOptions(
+			c.kubeClient,
+			resourcesSyncInterval,
+			informers.WithNamespace(namespace),
+		),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	ns.Ingress, ns.IngressHandlerSynced = k8s.WatchIngresses(k8s.IngressParams{
+		InformerFactory: ns.factory,
+	}, k8s.IngressHandlers{
+		AddFunc:    c.onIngressAdded,
+		UpdateFunc: c.onIngressUpdated,
+		DeleteFunc: c.onIngressDeleted,
+	})
+	ns.Service = k8s.WatchServices(k8s.ServiceParams{
+		InformerFactory: ns.factory,
+	}, k8s.ServiceHandlers{
+		AddFunc:    c.onServiceAdded,
+		UpdateFunc: c.onServiceUpdated,
+		DeleteFunc: c.onServiceDeleted,
+	})
+	ns.factory.Start(ctx.Done())
+	c.namespaces[namespace] = ns
+
+	if namespace != metav1.NamespaceAll {
+		c.logger.Infof("watching namespace %s", namespace)
+	}
+
+	if c.watchingEndpointSlices {
+		if err := c.watchNamespaceEndpointSlices(ns); err != nil {
+			return ns, err
+		}
+	}
+	if c.watchingTLSSecrets {
+		return ns, c.watchNamespaceTLSSecrets(ns)
+	}
+	return ns, nil
+}
+
+// unwatchNamespace stops the informers of a namespace and removes its ingresses from the store
+// and its TLS secrets from the CertFolder.
+func (c *CaddyController) unwatchNamespace(namespace string) {
+	ns, ok := c.namespaces[namespace]
+	if !ok {
+		return
+	}
+
+	c.logger.Infof("not watching namespace %s anymore", namespace)
+	ns.cancel()
+	delete(c.namespaces, namespace)
+
+	for _, ing := range slices.Clone(c.resourceStore.Ingresses) {
+		if ing.Namespace == namespace {
+			c.resourceStore.PluckIngress(ing)
+		}
+	}
+
+	if ns.TLSSecret != nil {
+		for _, obj := range ns.TLSSecret.GetStore().List() {
+			secret := obj.(*apiv1.Secret)
+			if err := removeFile(secret); err != nil {
+				c.logger.Warnf("could not remove TLS secret %s/%s: %v", secret.Namespace, secret.Name, err)
+			}
+		}
+	}
+}
+
+// ingressSelectorFor returns the ingress label selector to use with the global options,
+// the ConfigMap option takes precedence over the flag.
+func (c *CaddyController) ingressSelectorFor(cfg *store.ConfigMapOptions) string {
+	if cfg != nil && cfg.IngressLabelSelector != "" {
+		return cfg.IngressLabelSelector
+	}
+	return c.resourceStore.Options.IngressLabelSelector
+}
+
+// applyIngressSelector syncs the store with the watched ingresses when the ingress label
+// selector changed, so that ingresses that do not match anymore are removed from the store
+// and the others added. Ingress informers watch all ingresses, they are not restarted.
+func (c *CaddyController) applyIngressSelector() {
+	selector := c.ingressSelectorFor(c.resourceStore.ConfigMap)
+	if selector == c.ingressSelector {
+		return
+	}
+
+	c.logger.Infof("ingress label selector changed from %q to %q", c.ingressSelector, selector)
+	c.ingressSelector = selector
+	c.syncAllIngresses()
+}
+
+// matchesIngressSelector returns whether the labels of the ingress match the ingress label selector.
+func (c *CaddyController) matchesIngressSelector(ing *networkingv1.Ingress) bool {
+	// the selector is validated with the flag and the ConfigMap
+	selector, err := labels.Parse(c.ingressSelector)
+	return err == nil && selector.Matches(labels.Set(ing.Labels))
+}
+
+// syncAllIngresses adds the watched ingresses controlled by us to the store, and removes the others.
+func (c *CaddyController) syncAllIngresses() {
+	for _, ns := range c.namespaces {
+		for _, obj := range ns.Ingress.GetStore().List() {
+			c.syncIngress(obj.(*networkingv1.Ingress))
+		}
+	}
+}
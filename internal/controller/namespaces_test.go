@@ -0,0 +1,221 @@
+package controller
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/caddyserver/ingress/internal/k8s"
+	"github.com/caddyserver/ingress/pkg/store"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestIsWatchedNamespace(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		namespaces []string
+		selector   string
+		expected   map[string]bool
+	}{
+		{
+			desc:     "all namespaces",
+			expected: map[string]bool{"blue-a": true, "blue-b": true, "red": true},
+		},
+		{
+			desc:       "listed namespaces",
+			namespaces: []string{"blue-a", "red"},
+			expected:   map[string]bool{"blue-a": true, "blue-b": false, "red": true},
+		},
+		{
+			desc:     "namespaces matching selector",
+			selector: "group=blue",
+			expected: map[string]bool{"blue-a": true, "blue-b": true, "red": false},
+		},
+		{
+			desc:       "listed namespaces matching selector",
+			namespaces: []string{"blue-a", "red"},
+			selector:   "group=blue",
+			expected:   map[string]bool{"blue-a": true, "blue-b": false, "red": false},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			selector, err := labels.Parse(tC.selector)
+			require.NoError(t, err)
+			c := &CaddyController{watchNamespaces: tC.namespaces, namespaceSelector: selector}
+
+			for name, expected := range tC.expected {
+				group := "blue"
+				if name == "red" {
+					group = "red"
+				}
+				ns := &apiv1.Namespace{ObjectMeta: metav1.ObjectMeta{
+					Name:   name,
+					Labels: map[string]string{"group": group},
+				}}
+				require.Equal(t, expected, c.isWatchedNamespace(ns), name)
+			}
+		})
+	}
+}
+
+func TestUnwatchNamespaceRemovesIngresses(t *testing.T) {
+	s := store.NewStore(store.Options{}, "", &store.PodInfo{})
+	for i, ns := range []string{"blue", "red", "blue"} {
+		s.AddIngress(&networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{
+			Namespace: ns,
+			UID:       types.UID(fmt.Sprintf("%s-%d", ns, i)),
+		}})
+	}
+
+	canceled := false
+	c := &CaddyController{
+		logger:        zap.NewNop().Sugar(),
+		resourceStore: s,
+		namespaces: map[string]*namespaceInformers{
+			"blue": {cancel: func() { canceled = true }},
+		},
+	}
+
+	c.unwatchNamespace("blue")
+	require.True(t, canceled)
+	require.Empty(t, c.namespaces)
+	require.Len(t, s.Ingresses, 1)
+	require.Equal(t, "red", s.Ingresses[0].Namespace)
+
+	// unknown namespaces are ignored
+	c.unwatchNamespace("green")
+	require.Len(t, s.Ingresses, 1)
+}
+
+func TestUnwatchNamespaceRemovesTLSSecrets(t *testing.T) {
+	folder := certFolder
+	certFolder = t.TempDir()
+	t.Cleanup(func() { certFolder = folder })
+
+	// secretInformer returns a TLS secret informer holding secrets of the namespace
+	secretInformer := func(namespace string, names ...string) cache.SharedIndexInformer {
+		f := informers.NewSharedInformerFactory(fake.NewClientset(), 0)
+		informer := f.Core().V1().Secrets().Informer()
+		for _, name := range names {
+			secret := &apiv1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+			require.NoError(t, informer.GetIndexer().Add(secret))
+			require.NoError(t, writeFile(secret))
+		}
+		return informer
+	}
+
+	c := &CaddyController{
+		logger:        zap.NewNop().Sugar(),
+		resourceStore: store.NewStore(store.Options{}, "", &store.PodInfo{}),
+		namespaces: map[string]*namespaceInformers{
+			"blue": {cancel: func() {}, TLSSecret: secretInformer("blue", "blue-tls", "shared-tls")},
+			"red":  {cancel: func() {}, TLSSecret: secretInformer("red", "red-tls", "shared-tls")},
+		},
+	}
+
+	// secrets with the same name in different namespaces have their own file
+	require.FileExists(t, filepath.Join(certFolder, "blue_shared-tls.pem"))
+	require.FileExists(t, filepath.Join(certFolder, "red_shared-tls.pem"))
+
+	c.unwatchNamespace("blue")
+	require.NoFileExists(t, filepath.Join(certFolder, "blue_blue-tls.pem"))
+	require.NoFileExists(t, filepath.Join(certFolder, "blue_shared-tls.pem"))
+	require.FileExists(t, filepath.Join(certFolder, "red_red-tls.pem"))
+	require.FileExists(t, filepath.Join(certFolder, "red_shared-tls.pem"))
+
+	c.unwatchNamespace("red")
+	files, err := os.ReadDir(certFolder)
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
+
+func TestIngressSelectorFor(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		flag     string
+		cfg      *store.ConfigMapOptions
+		expected string
+	}{
+		{
+			desc:     "no selector",
+			expected: "",
+		},
+		{
+			desc:     "flag only",
+			flag:     "shard=stable",
+			expected: "shard=stable",
+		},
+		{
+			desc:     "config map without selector",
+			flag:     "shard=stable",
+			cfg:      &store.ConfigMapOptions{},
+			expected: "shard=stable",
+		},
+		{
+			desc:     "config map takes precedence",
+			flag:     "shard=stable",
+			cfg:      &store.ConfigMapOptions{IngressLabelSelector: "shard=canary"},
+			expected: "shard=canary",
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			c := &CaddyController{resourceStore: store.NewStore(store.Options{IngressLabelSelector: tC.flag}, "", &store.PodInfo{})}
+			require.Equal(t, tC.expected, c.ingressSelectorFor(tC.cfg))
+		})
+	}
+}
+
+func TestApplyIngressSelector(t *testing.T) {
+	factory := informers.NewSharedInformerFactory(fake.NewClientset(), 0)
+	ingressInformer := factory.Networking().V1().Ingresses().Informer()
+	for _, shard := range []string{"stable", "canary"} {
+		require.NoError(t, ingressInformer.GetIndexer().Add(&networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{
+			Name:      shard,
+			Namespace: "default",
+			UID:       types.UID(shard),
+			Labels:    map[string]string{"shard": shard},
+		}}))
+	}
+
+	ns := &namespaceInformers{factory: factory, Ingress: ingressInformer}
+	c := &CaddyController{
+		logger:        zap.NewNop().Sugar(),
+		resourceStore: store.NewStore(store.Options{IngressLabelSelector: "shard=stable"}, "", &store.PodInfo{}),
+		namespaces:    map[string]*namespaceInformers{metav1.NamespaceAll: ns},
+		ingressClasses: k8s.IngressClassMatcher{
+			ClassName: "caddy",
+			Lister:    factory.Networking().V1().IngressClasses().Lister(),
+		},
+	}
+	storedIngresses := func() []string {
+		var names []string
+		for _, ing := range c.resourceStore.Ingresses {
+			names = append(names, ing.Name)
+		}
+		return names
+	}
+
+	c.applyIngressSelector()
+	require.Equal(t, []string{"stable"}, storedIngresses())
+
+	// the selector of the ConfigMap applies to the cached ingresses, informers are kept
+	c.resourceStore.ConfigMap.IngressLabelSelector = "shard=canary"
+	c.applyIngressSelector()
+	require.Equal(t, []string{"canary"}, storedIngresses())
+	require.Same(t, ns, c.namespaces[metav1.NamespaceAll])
+	require.Same(t, ingressInformer, ns.Ingress)
+}
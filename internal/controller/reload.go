@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"time"
+)
+
+// reloadDebouncer coalesces reload requests. Once a request is received, reload is called
+// when no other request was received for `window`, and at most `maxDelay` after the first
+// pending request so that a constant flow of changes cannot delay reloads forever.
+type reloadDebouncer struct {
+	window   time.Duration
+	maxDelay time.Duration
+	reload   func()
+
+	requests chan struct{}
+}
+
+func newReloadDebouncer(window, maxDelay time.Duration, reload func()) *reloadDebouncer {
+	return &reloadDebouncer{
+		window:   window,
+		maxDelay: maxDelay,
+		reload:   reload,
+		requests: make(chan struct{}, 1),
+	}
+}
+
+// Request asks for a reload, it never blocks.
+func (d *reloadDebouncer) Request() {
+	select {
+	case d.requests <- struct{}{}:
+	default:
+		// a request is already pending
+	}
+}
+
+// Run handles reload requests until stopCh is closed.
+func (d *reloadDebouncer) Run(stopCh <-chan struct{}) {
+	var window, deadline *time.Timer
+	var windowC, deadlineC <-chan time.Time
+
+	fire := func() {
+		window.Stop()
+		deadline.Stop()
+		windowC, deadlineC = nil, nil
+		d.reload()
+	}
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-d.requests:
+			if deadlineC == nil {
+				deadline = time.NewTimer(d.maxDelay)
+				deadlineC = deadline.C
+			}
+			if window != nil {
+				window.Stop()
+			}
+			window = time.NewTimer(d.window)
+			windowC = window.C
+		case <-windowC:
+			fire()
+		case <-deadlineC:
+			fire()
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloadDebouncerCoalescesRequests(t *testing.T) {
+	var reloads atomic.Int32
+	d := newReloadDebouncer(50*time.Millisecond, time.Second, func() { reloads.Add(1) })
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go d.Run(stopCh)
+
+	for range 200 {
+		d.Request()
+	}
+
+	require.Eventually(t, func() bool { return reloads.Load() == 1 }, time.Second, 10*time.Millisecond)
+
+	// no more reloads once requests stopped
+	time.Sleep(100 * time.Millisecond)
+	require.Equal(t, int32(1), reloads.Load())
+}
+
+func TestReloadDebouncerBoundsDelay(t *testing.T) {
+	var reloads atomic.Int32
+	d := newReloadDebouncer(50*time.Millisecond, 100*time.Millisecond, func() { reloads.Add(1) })
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go d.Run(stopCh)
+
+	// requests keep coming faster than the debounce window
+	start := time.Now()
+	for range 30 {
+		d.Request()
+		time.Sleep(10 * time.Millisecond)
+	}
+	elapsed := time.Since(start)
+
+	// the reload does not wait for requests to stop, but still waits for maxDelay between reloads
+	require.GreaterOrEqual(t, reloads.Load(), int32(1), "reload should not wait for requests to stop")
+	require.LessOrEqual(t, reloads.Load(), int32(elapsed/(100*time.Millisecond)), "reloads should be coalesced for maxDelay")
+}
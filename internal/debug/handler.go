@@ -0,0 +1,83 @@
+package debug
+
+import (
+	"net/http"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// PathPrefix is the path under which debug endpoints are served.
+const PathPrefix = "/debug"
+
+func init() {
+	caddy.RegisterModule(Handler{})
+}
+
+// Handler serves the controller state snapshots:
+//   - /debug/config: the applied caddy config
+//   - /debug/config/previous: the caddy config applied before it
+//   - /debug/config/diff: a unified diff between both configs
+//   - /debug/store: the resources used to generate the config
+//   - /debug/plugins: the converter plugins in the order they run
+//   - /debug/ingresses: the routes generated for each Ingress
+type Handler struct{}
+
+func (Handler) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID:  "http.handlers.ingress_debug",
+		New: func() caddy.Module { return new(Handler) },
+	}
+}
+
+func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request, next caddyhttp.Handler) error {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return caddyhttp.Error(http.StatusMethodNotAllowed, nil)
+	}
+
+	state.RLock()
+	defer state.RUnlock()
+
+	switch r.URL.Path {
+	case PathPrefix + "/config":
+		return writeJSON(w, state.applied)
+	case PathPrefix + "/config/previous":
+		return writeJSON(w, state.previous)
+	case PathPrefix + "/config/diff":
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(string(state.previous)),
+			B:        difflib.SplitLines(string(state.applied)),
+			FromFile: "previous",
+			ToFile:   "applied",
+			Context:  3,
+		})
+		if err != nil {
+			return caddyhttp.Error(http.StatusInternalServerError, err)
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, err = w.Write([]byte(diff))
+		return err
+	case PathPrefix + "/store":
+		return writeJSON(w, state.store)
+	case PathPrefix + "/plugins":
+		return writeJSON(w, state.plugins)
+	case PathPrefix + "/ingresses":
+		return writeJSON(w, state.ingresses)
+	}
+	return next.ServeHTTP(w, r)
+}
+
+func writeJSON(w http.ResponseWriter, j []byte) error {
+	if j == nil {
+		return caddyhttp.Error(http.StatusNotFound, nil)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err := w.Write(j)
+	return err
+}
+
+// Interface guards
+var (
+	_ caddyhttp.MiddlewareHandler = (*Handler)(nil)
+)
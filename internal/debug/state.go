@@ -0,0 +1,126 @@
+// Package debug serves read-only snapshots of the controller state on the metrics server.
+// Snapshots are taken by the controller worker, so that handlers never read the store directly.
+package debug
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// redacted replaces sensitive values in snapshots.
+const redacted = "[REDACTED]"
+
+// sensitiveConfigKeys are the keys of a caddy config holding secrets.
+var sensitiveConfigKeys = map[string]bool{
+	"mac_key": true,
+	// cookie load balancing policy
+	"secret": true,
+}
+
+// sensitiveHeaderKeys are the keys of a caddy config holding headers that may carry
+// credentials, by the key of the object holding them. All header values are redacted.
+var sensitiveHeaderKeys = map[string]string{
+	// active health checks of upstreams
+	"active": "headers",
+}
+
+var state = struct {
+	sync.RWMutex
+	applied   []byte
+	previous  []byte
+	store     []byte
+	plugins   []byte
+	ingresses []byte
+}{}
+
+// SetAppliedConfig records a caddy config that was loaded, the config loaded before it
+// is kept as the previous config.
+func SetAppliedConfig(config []byte) error {
+	j, err := redactConfig(config)
+	if err != nil {
+		return err
+	}
+
+	state.Lock()
+	defer state.Unlock()
+	state.previous, state.applied = state.applied, j
+	return nil
+}
+
+// SetConversion records the inputs and per-Ingress outputs of the last conversion.
+func SetConversion(store, plugins, ingresses any) error {
+	s, err := json.MarshalIndent(store, "", "  ")
+	if err != nil {
+		return err
+	}
+	p, err := json.MarshalIndent(plugins, "", "  ")
+	if err != nil {
+		return err
+	}
+	i, err := json.Marshal(ingresses)
+	if err != nil {
+		return err
+	}
+	if i, err = redactConfig(i); err != nil {
+		return err
+	}
+
+	state.Lock()
+	defer state.Unlock()
+	state.store, state.plugins, state.ingresses = s, p, i
+	return nil
+}
+
+// redactConfig returns an indented copy of a caddy config without its secrets.
+func redactConfig(config []byte) ([]byte, error) {
+	var v any
+	if err := json.Unmarshal(config, &v); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(redactValue(v, ""), "", "  ")
+}
+
+// redactValue redacts the secrets of a value found under the key parent.
+func redactValue(v any, parent string) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			if s, ok := val.(string); ok && s != "" && sensitiveConfigKeys[k] {
+				t[k] = redacted
+				continue
+			}
+			if headers, ok := val.(map[string]any); ok && sensitiveHeaderKeys[parent] == k {
+				t[k] = redactHeaders(headers)
+				continue
+			}
+			t[k] = redactValue(val, k)
+		}
+	case []any:
+		for i, val := range t {
+			t[i] = redactValue(val, parent)
+		}
+	}
+	return v
+}
+
+// redactHeaders redacts the values of headers.
+func redactHeaders(headers map[string]any) map[string]any {
+	for _, values := range headers {
+		if values, ok := values.([]any); ok {
+			for i, val := range values {
+				if s, ok := val.(string); ok {
+					values[i] = Redact(s)
+				}
+			}
+		}
+	}
+	return headers
+}
+
+// Redact returns a placeholder for a sensitive value, keeping empty values empty.
+func Redact(s string) string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
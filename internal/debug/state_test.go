@@ -0,0 +1,50 @@
+package debug
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetAppliedConfigRedactsSecrets(t *testing.T) {
+	config := `{"apps":{"tls":{"automation":{"policies":[{"issuers":[{"module":"acme","external_account":{"key_id":"id","mac_key":"secret"}}]}]}}}}`
+
+	require.NoError(t, SetAppliedConfig([]byte(`{}`)))
+	require.NoError(t, SetAppliedConfig([]byte(config)))
+
+	require.NotContains(t, string(state.applied), "secret")
+	require.Contains(t, string(state.applied), `"mac_key": "[REDACTED]"`)
+	require.Contains(t, string(state.applied), `"key_id": "id"`)
+	require.Equal(t, "{}", string(state.previous))
+}
+
+func TestRedact(t *testing.T) {
+	require.Equal(t, "", Redact(""))
+	require.Equal(t, redacted, Redact("secret"))
+}
+
+func TestSetConversionRedactsSecrets(t *testing.T) {
+	routes := `{"namespace/ingress":[{"handle":[{"handler":"reverse_proxy","load_balancing":{"selection_policy":{"policy":"cookie","name":"lb","secret":"s3cr3t"}}}]}]}`
+
+	require.NoError(t, SetConversion(nil, nil, json.RawMessage(routes)))
+
+	require.NotContains(t, string(state.ingresses), "s3cr3t")
+	require.Contains(t, string(state.ingresses), `"secret": "[REDACTED]"`)
+	require.Contains(t, string(state.ingresses), `"name": "lb"`)
+}
+
+func TestSetConversionRedactsHealthCheckHeaders(t *testing.T) {
+	routes := `{"namespace/ingress":[{"handle":[
+		{"handler":"reverse_proxy","health_checks":{"active":{"uri":"/healthz","headers":{"Authorization":["Bearer t0k3n"]}}}},
+		{"handler":"headers","request":{"set":{"X-Forwarded-Prefix":["/app"]}}}
+	]}]}`
+
+	require.NoError(t, SetConversion(nil, nil, json.RawMessage(routes)))
+
+	require.NotContains(t, string(state.ingresses), "t0k3n")
+	require.Contains(t, string(state.ingresses), `"[REDACTED]"`)
+	require.Contains(t, string(state.ingresses), `"uri": "/healthz"`)
+	// other headers are kept
+	require.Contains(t, string(state.ingresses), `"/app"`)
+}
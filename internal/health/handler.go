@@ -0,0 +1,106 @@
+package health
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+	"github.com/caddyserver/caddy/v2/modules/caddytls"
+)
+
+const (
+	// Liveness fails when the controller worker is stuck.
+	Liveness = "liveness"
+
+	// Readiness fails until the controller is able to serve traffic.
+	Readiness = "readiness"
+)
+
+func init() {
+	caddy.RegisterModule(Handler{})
+}
+
+// Handler responds to a liveness or a readiness probe.
+type Handler struct {
+	Check string `json:"check"`
+
+	// Liveness fails when the worker processes a single item for longer than this.
+	StuckTimeout caddy.Duration `json:"stuck_timeout,omitempty"`
+
+	// Readiness fails until certificates for these subjects are loaded from TLS secrets.
+	Subjects []string `json:"subjects,omitempty"`
+
+	tlsApp *caddytls.TLS
+}
+
+func (Handler) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID:  "http.handlers.ingress_health",
+		New: func() caddy.Module { return new(Handler) },
+	}
+}
+
+// Provision gets the TLS app to look certificates up.
+func (h *Handler) Provision(ctx caddy.Context) error {
+	if h.Check != Liveness && h.Check != Readiness {
+		return fmt.Errorf("unknown check %q", h.Check)
+	}
+
+	if len(h.Subjects) > 0 {
+		app, err := ctx.App("tls")
+		if err != nil {
+			return err
+		}
+		h.tlsApp = app.(*caddytls.TLS)
+	}
+	return nil
+}
+
+func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request, next caddyhttp.Handler) error {
+	var err error
+	if h.Check == Liveness {
+		err = h.live()
+	} else {
+		err = h.ready()
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, err = w.Write([]byte(err.Error()))
+		return err
+	}
+	_, err = w.Write([]byte("ok"))
+	return err
+}
+
+func (h Handler) live() error {
+	busy := workerBusyFor()
+	if h.StuckTimeout > 0 && busy > time.Duration(h.StuckTimeout) {
+		return fmt.Errorf("worker is stuck on the same item for %v", busy.Round(time.Second))
+	}
+	return nil
+}
+
+func (h Handler) ready() error {
+	if !state.cachesSynced.Load() {
+		return fmt.Errorf("caches are not synced")
+	}
+	if !state.syncedConfigLoaded.Load() {
+		return fmt.Errorf("config generated from the cluster state is not loaded")
+	}
+	for _, subject := range h.Subjects {
+		if !h.tlsApp.HasCertificateForSubject(subject) {
+			return fmt.Errorf("certificate for %s is not loaded", subject)
+		}
+	}
+	return nil
+}
+
+// Interface guards
+var (
+	_ caddy.Provisioner           = (*Handler)(nil)
+	_ caddyhttp.MiddlewareHandler = (*Handler)(nil)
+)
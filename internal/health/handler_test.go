@@ -0,0 +1,47 @@
+package health
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadiness(t *testing.T) {
+	h := Handler{Check: Readiness}
+	require.EqualError(t, h.ready(), "caches are not synced")
+
+	SetCachesSynced()
+
+	// a config restored from history is not enough
+	require.EqualError(t, h.ready(), "config generated from the cluster state is not loaded")
+	SetSyncedConfigLoaded()
+	require.NoError(t, h.ready())
+
+	w := httptest.NewRecorder()
+	require.NoError(t, h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil), nil))
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestLivenessDetectsStuckWorker(t *testing.T) {
+	h := Handler{Check: Liveness, StuckTimeout: caddy.Duration(time.Minute)}
+	require.NoError(t, h.live())
+
+	state.busySince.Store(time.Now().Add(-2 * time.Minute).UnixNano())
+	require.Error(t, h.live())
+
+	w := httptest.NewRecorder()
+	require.NoError(t, h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil), nil))
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	WorkerIdle()
+	require.NoError(t, h.live())
+
+	// detection is disabled without timeout
+	state.busySince.Store(time.Now().Add(-time.Hour).UnixNano())
+	require.NoError(t, Handler{Check: Liveness}.live())
+	WorkerIdle()
+}
@@ -0,0 +1,47 @@
+// Package health serves the liveness and readiness probes of the controller on the metrics server.
+package health
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+var state struct {
+	cachesSynced atomic.Bool
+
+	// whether caddy runs a config generated from the synced caches, the config restored
+	// from history at startup may be stale
+	syncedConfigLoaded atomic.Bool
+
+	// when the worker started to process its current item, 0 when idle
+	busySince atomic.Int64
+}
+
+// SetCachesSynced records that the informer caches synced.
+func SetCachesSynced() {
+	state.cachesSynced.Store(true)
+}
+
+// SetSyncedConfigLoaded records that caddy loaded a config generated from the synced caches.
+func SetSyncedConfigLoaded() {
+	state.syncedConfigLoaded.Store(true)
+}
+
+// WorkerBusy records that the worker started processing an item.
+func WorkerBusy() {
+	state.busySince.Store(time.Now().UnixNano())
+}
+
+// WorkerIdle records that the worker is done with its item.
+func WorkerIdle() {
+	state.busySince.Store(0)
+}
+
+// workerBusyFor returns for how long the worker has been processing its current item.
+func workerBusyFor() time.Duration {
+	since := state.busySince.Load()
+	if since == 0 {
+		return 0
+	}
+	return time.Since(time.Unix(0, since))
+}
@@ -22,10 +22,12 @@ func isControllerConfigMap(cm *v1.ConfigMap, name string) bool {
 	return cm.GetName() == name
 }
 
-func WatchConfigMaps(options ConfigMapParams, funcs ConfigMapHandlers) cache.SharedIndexInformer {
+// WatchConfigMaps registers handlers for the controller ConfigMap.
+// It returns the informer and a function reporting whether handlers received the initial list.
+func WatchConfigMaps(options ConfigMapParams, funcs ConfigMapHandlers) (cache.SharedIndexInformer, cache.InformerSynced) {
 	informer := options.InformerFactory.Core().V1().ConfigMaps().Informer()
 
-	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+	registration, _ := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj any) {
 			cm, ok := obj.(*v1.ConfigMap)
 
@@ -50,5 +52,73 @@ func WatchConfigMaps(options ConfigMapParams, funcs ConfigMapHandlers) cache.Sha
 		},
 	})
 
+	return informer, registration.HasSynced
+}
+
+type ResourceConfigMapParams struct {
+	InformerFactory informers.SharedInformerFactory
+	ConfigMapName   string
+}
+
+// WatchResourceConfigMap registers handlers for the ConfigMap of the factory with the given name,
+// used to serve the content of Resource backends.
+func WatchResourceConfigMap(options ResourceConfigMapParams, funcs ConfigMapHandlers) cache.SharedIndexInformer {
+	informer := options.InformerFactory.Core().V1().ConfigMaps().Informer()
+
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj any) {
+			cm, ok := obj.(*v1.ConfigMap)
+
+			if ok && cm.Name == options.ConfigMapName {
+				funcs.AddFunc(cm)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj any) {
+			oldCM, ok1 := oldObj.(*v1.ConfigMap)
+			newCM, ok2 := newObj.(*v1.ConfigMap)
+
+			if ok1 && ok2 && newCM.Name == options.ConfigMapName {
+				funcs.UpdateFunc(oldCM, newCM)
+			}
+		},
+		DeleteFunc: func(obj any) {
+			cm, ok := obj.(*v1.ConfigMap)
+
+			if ok && cm.Name == options.ConfigMapName {
+				funcs.DeleteFunc(cm)
+			}
+		},
+	})
+
+	return informer
+}
+
+type ClassParametersParams struct {
+	InformerFactory informers.SharedInformerFactory
+}
+
+// WatchClassParameters registers a handler for all the ConfigMaps of the factory, any of them
+// may hold the parameters of an IngressClass.
+func WatchClassParameters(options ClassParametersParams, changed func(cm *v1.ConfigMap)) cache.SharedIndexInformer {
+	informer := options.InformerFactory.Core().V1().ConfigMaps().Informer()
+
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj any) {
+			if cm, ok := obj.(*v1.ConfigMap); ok {
+				changed(cm)
+			}
+		},
+		UpdateFunc: func(_, newObj any) {
+			if cm, ok := newObj.(*v1.ConfigMap); ok {
+				changed(cm)
+			}
+		},
+		DeleteFunc: func(obj any) {
+			if cm, ok := obj.(*v1.ConfigMap); ok {
+				changed(cm)
+			}
+		},
+	})
+
 	return informer
 }
@@ -0,0 +1,46 @@
+package k8s
+
+import (
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+type EndpointSliceHandlers struct {
+	AddFunc    func(obj *discoveryv1.EndpointSlice)
+	UpdateFunc func(oldObj, newObj *discoveryv1.EndpointSlice)
+	DeleteFunc func(obj *discoveryv1.EndpointSlice)
+}
+
+type EndpointSliceParams struct {
+	InformerFactory informers.SharedInformerFactory
+}
+
+// WatchEndpointSlices registers handlers for EndpointSlice resources, used to route
+// ingresses directly to the pods of their services.
+func WatchEndpointSlices(options EndpointSliceParams, funcs EndpointSliceHandlers) cache.SharedIndexInformer {
+	informer := options.InformerFactory.Discovery().V1().EndpointSlices().Informer()
+
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj any) {
+			if slice, ok := obj.(*discoveryv1.EndpointSlice); ok {
+				funcs.AddFunc(slice)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj any) {
+			oldSlice, ok1 := oldObj.(*discoveryv1.EndpointSlice)
+			newSlice, ok2 := newObj.(*discoveryv1.EndpointSlice)
+
+			if ok1 && ok2 {
+				funcs.UpdateFunc(oldSlice, newSlice)
+			}
+		},
+		DeleteFunc: func(obj any) {
+			if slice, ok := obj.(*discoveryv1.EndpointSlice); ok {
+				funcs.DeleteFunc(slice)
+			}
+		},
+	})
+
+	return informer
+}
@@ -18,19 +18,19 @@ type IngressHandlers struct {
 }
 
 type IngressParams struct {
-	InformerFactory   informers.SharedInformerFactory
-	ClassName         string
-	ClassNameRequired bool
+	InformerFactory informers.SharedInformerFactory
 }
 
-func WatchIngresses(options IngressParams, funcs IngressHandlers) cache.SharedIndexInformer {
+// WatchIngresses registers handlers for Ingress resources.
+// Whether an Ingress is controlled by us depends on IngressClass resources and on the
+// ingress label selector, which can change at runtime, so it is decided when handling it.
+// It returns the informer and a function reporting whether handlers received the initial list.
+func WatchIngresses(options IngressParams, funcs IngressHandlers) (cache.SharedIndexInformer, cache.InformerSynced) {
 	informer := options.InformerFactory.Networking().V1().Ingresses().Informer()
 
-	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+	registration, _ := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj any) {
-			ingress, ok := obj.(*networkingv1.Ingress)
-
-			if ok && isControllerIngress(options, ingress) {
+			if ingress, ok := obj.(*networkingv1.Ingress); ok {
 				funcs.AddFunc(ingress)
 			}
 		},
@@ -38,37 +38,21 @@ func WatchIngresses(options IngressParams, funcs IngressHandlers) cache.SharedIn
 			oldIng, ok1 := oldObj.(*networkingv1.Ingress)
 			newIng, ok2 := newObj.(*networkingv1.Ingress)
 
-			if ok1 && ok2 && isControllerIngress(options, newIng) {
+			if ok1 && ok2 {
 				funcs.UpdateFunc(oldIng, newIng)
 			}
 		},
 		DeleteFunc: func(obj any) {
-			ingress, ok := obj.(*networkingv1.Ingress)
-
-			if ok && isControllerIngress(options, ingress) {
+			if ingress, ok := obj.(*networkingv1.Ingress); ok {
 				funcs.DeleteFunc(ingress)
 			}
 		},
 	})
 
-	return informer
-}
-
-// isControllerIngress check if the ingress object can be controlled by us
-func isControllerIngress(options IngressParams, ingress *networkingv1.Ingress) bool {
-	ingressClass := ingress.Annotations["kubernetes.io/ingress.class"]
-	if ingressClass == "" && ingress.Spec.IngressClassName != nil {
-		ingressClass = *ingress.Spec.IngressClassName
-	}
-
-	if !options.ClassNameRequired && ingressClass == "" {
-		return true
-	}
-
-	return ingressClass == options.ClassName
+	return informer, registration.HasSynced
 }
 
-func UpdateIngressStatus(kubeClient *kubernetes.Clientset, ing *networkingv1.Ingress, status []networkingv1.IngressLoadBalancerIngress) (*networkingv1.Ingress, error) {
+func UpdateIngressStatus(kubeClient kubernetes.Interface, ing *networkingv1.Ingress, status []networkingv1.IngressLoadBalancerIngress) (*networkingv1.Ingress, error) {
 	ingClient := kubeClient.NetworkingV1().Ingresses(ing.Namespace)
 
 	currIng, err := ingClient.Get(context.TODO(), ing.Name, metav1.GetOptions{})
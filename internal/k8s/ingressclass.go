@@ -0,0 +1,117 @@
+package k8s
+
+import (
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	networkinglisters "k8s.io/client-go/listers/networking/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// legacy annotation used to set the class of an Ingress before spec.ingressClassName
+	IngressClassAnnotation = "kubernetes.io/ingress.class"
+
+	// annotation marking the IngressClass of Ingresses without class
+	DefaultIngressClassAnnotation = "ingressclass.kubernetes.io/is-default-class"
+)
+
+type IngressClassHandlers struct {
+	AddFunc    func(obj *networkingv1.IngressClass)
+	UpdateFunc func(oldObj, newObj *networkingv1.IngressClass)
+	DeleteFunc func(obj *networkingv1.IngressClass)
+}
+
+type IngressClassParams struct {
+	InformerFactory informers.SharedInformerFactory
+}
+
+// WatchIngressClasses registers handlers for all IngressClass resources, as any of them may
+// change which Ingresses are controlled by us.
+// It returns the informer and a function reporting whether handlers received the initial list.
+func WatchIngressClasses(options IngressClassParams, funcs IngressClassHandlers) (cache.SharedIndexInformer, cache.InformerSynced) {
+	informer := options.InformerFactory.Networking().V1().IngressClasses().Informer()
+
+	registration, _ := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj any) {
+			if class, ok := obj.(*networkingv1.IngressClass); ok {
+				funcs.AddFunc(class)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj any) {
+			oldClass, ok1 := oldObj.(*networkingv1.IngressClass)
+			newClass, ok2 := newObj.(*networkingv1.IngressClass)
+
+			if ok1 && ok2 {
+				funcs.UpdateFunc(oldClass, newClass)
+			}
+		},
+		DeleteFunc: func(obj any) {
+			if class, ok := obj.(*networkingv1.IngressClass); ok {
+				funcs.DeleteFunc(class)
+			}
+		},
+	})
+
+	return informer, registration.HasSynced
+}
+
+// IngressClassMatcher decides which Ingresses are controlled by us.
+type IngressClassMatcher struct {
+	// class name matched by the legacy annotation and spec.ingressClassName
+	ClassName string
+	// whether Ingresses without class are ignored when there is no default IngressClass
+	ClassNameRequired bool
+	// IngressClasses with this spec.controller are controlled by us
+	ControllerName string
+
+	Lister networkinglisters.IngressClassLister
+}
+
+// Match returns whether the Ingress is controlled by us, and its IngressClass if it exists.
+func (m IngressClassMatcher) Match(ing *networkingv1.Ingress) (*networkingv1.IngressClass, bool) {
+	if className, ok := ing.Annotations[IngressClassAnnotation]; ok && className != "" {
+		return nil, className == m.ClassName
+	}
+
+	if ing.Spec.IngressClassName != nil && *ing.Spec.IngressClassName != "" {
+		className := *ing.Spec.IngressClassName
+		class, err := m.Lister.Get(className)
+		if err != nil {
+			// without IngressClass, the class name is all we know about
+			return nil, className == m.ClassName
+		}
+		// an existing IngressClass decides, even if it has our class name
+		return class, m.IsControllerClass(class)
+	}
+
+	if class := m.defaultClass(); class != nil {
+		return class, m.IsControllerClass(class)
+	}
+	return nil, !m.ClassNameRequired
+}
+
+// IsControllerClass returns whether the IngressClass is implemented by us.
+func (m IngressClassMatcher) IsControllerClass(class *networkingv1.IngressClass) bool {
+	return class.Spec.Controller == m.ControllerName
+}
+
+// defaultClass returns the IngressClass of Ingresses without class.
+// When multiple classes are marked as default, the most recent one wins.
+func (m IngressClassMatcher) defaultClass() *networkingv1.IngressClass {
+	classes, err := m.Lister.List(labels.Everything())
+	if err != nil {
+		return nil
+	}
+
+	var defaultClass *networkingv1.IngressClass
+	for _, class := range classes {
+		if class.Annotations[DefaultIngressClassAnnotation] != "true" {
+			continue
+		}
+		if defaultClass == nil || defaultClass.CreationTimestamp.Before(&class.CreationTimestamp) {
+			defaultClass = class
+		}
+	}
+	return defaultClass
+}
@@ -0,0 +1,94 @@
+package k8s
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	networkinglisters "k8s.io/client-go/listers/networking/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func createIngressClass(name, controller string, isDefault bool, created time.Time) *networkingv1.IngressClass {
+	class := &networkingv1.IngressClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Annotations:       map[string]string{},
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: networkingv1.IngressClassSpec{Controller: controller},
+	}
+	if isDefault {
+		class.Annotations[DefaultIngressClassAnnotation] = "true"
+	}
+	return class
+}
+
+func createMatcher(t *testing.T, classNameRequired bool, classes ...*networkingv1.IngressClass) IngressClassMatcher {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, class := range classes {
+		require.NoError(t, indexer.Add(class))
+	}
+	return IngressClassMatcher{
+		ClassName:         "caddy",
+		ClassNameRequired: classNameRequired,
+		ControllerName:    "caddy.io/ingress-controller",
+		Lister:            networkinglisters.NewIngressClassLister(indexer),
+	}
+}
+
+func TestIngressClassMatcher(t *testing.T) {
+	now := time.Now()
+	ours := createIngressClass("public", "caddy.io/ingress-controller", false, now)
+	theirs := createIngressClass("nginx", "k8s.io/ingress-nginx", false, now)
+	theirsNamedLikeOurs := createIngressClass("caddy", "k8s.io/ingress-nginx", false, now)
+	oursDefault := createIngressClass("public-default", "caddy.io/ingress-controller", true, now)
+	theirsDefault := createIngressClass("nginx-default", "k8s.io/ingress-nginx", true, now.Add(time.Minute))
+
+	testCases := []struct {
+		desc              string
+		classes           []*networkingv1.IngressClass
+		classNameRequired bool
+		annotation        string
+		className         string
+		expectedClass     string
+		expectedMatch     bool
+	}{
+		{desc: "legacy annotation matching class name", annotation: "caddy", expectedMatch: true},
+		{desc: "legacy annotation of another class", annotation: "nginx", className: "caddy", expectedMatch: false},
+		{desc: "class name without IngressClass", className: "caddy", expectedMatch: true},
+		{desc: "IngressClass controlled by us", classes: []*networkingv1.IngressClass{ours}, className: "public", expectedClass: "public", expectedMatch: true},
+		{desc: "IngressClass of another controller", classes: []*networkingv1.IngressClass{theirs}, className: "nginx", expectedClass: "nginx", expectedMatch: false},
+		{desc: "IngressClass of another controller with our class name", classes: []*networkingv1.IngressClass{theirsNamedLikeOurs}, className: "caddy", expectedClass: "caddy", expectedMatch: false},
+		{desc: "unknown class", className: "unknown", expectedMatch: false},
+		{desc: "no class without default class", expectedMatch: true},
+		{desc: "no class without default class when required", classNameRequired: true, expectedMatch: false},
+		{desc: "no class with our default class", classes: []*networkingv1.IngressClass{oursDefault}, classNameRequired: true, expectedClass: "public-default", expectedMatch: true},
+		{desc: "no class with another default class", classes: []*networkingv1.IngressClass{theirs, theirsDefault}, expectedClass: "nginx-default", expectedMatch: false},
+		{desc: "most recent default class wins", classes: []*networkingv1.IngressClass{oursDefault, theirsDefault}, expectedClass: "nginx-default", expectedMatch: false},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			m := createMatcher(t, tC.classNameRequired, tC.classes...)
+
+			ing := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}}}
+			if tC.annotation != "" {
+				ing.Annotations[IngressClassAnnotation] = tC.annotation
+			}
+			if tC.className != "" {
+				ing.Spec.IngressClassName = &tC.className
+			}
+
+			class, match := m.Match(ing)
+			require.Equal(t, tC.expectedMatch, match)
+			if tC.expectedClass == "" {
+				require.Nil(t, class)
+			} else {
+				require.Equal(t, tC.expectedClass, class.Name)
+			}
+		})
+	}
+}
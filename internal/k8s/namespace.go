@@ -0,0 +1,47 @@
+package k8s
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+type NamespaceHandlers struct {
+	AddFunc    func(obj *v1.Namespace)
+	UpdateFunc func(oldObj, newObj *v1.Namespace)
+	DeleteFunc func(obj *v1.Namespace)
+}
+
+type NamespaceParams struct {
+	InformerFactory informers.SharedInformerFactory
+}
+
+// WatchNamespaces registers handlers for Namespace resources, to follow the namespaces
+// matching a label selector.
+// It returns the informer and a function reporting whether handlers received the initial list.
+func WatchNamespaces(options NamespaceParams, funcs NamespaceHandlers) (cache.SharedIndexInformer, cache.InformerSynced) {
+	informer := options.InformerFactory.Core().V1().Namespaces().Informer()
+
+	registration, _ := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj any) {
+			if ns, ok := obj.(*v1.Namespace); ok {
+				funcs.AddFunc(ns)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj any) {
+			oldNs, ok1 := oldObj.(*v1.Namespace)
+			newNs, ok2 := newObj.(*v1.Namespace)
+
+			if ok1 && ok2 {
+				funcs.UpdateFunc(oldNs, newNs)
+			}
+		},
+		DeleteFunc: func(obj any) {
+			if ns, ok := obj.(*v1.Namespace); ok {
+				funcs.DeleteFunc(ns)
+			}
+		},
+	})
+
+	return informer, registration.HasSynced
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"slices"
 
 	"github.com/caddyserver/ingress/pkg/store"
 	"go.uber.org/zap"
@@ -16,7 +17,7 @@ import (
 
 // GetAddresses gets the ip address or name of the node in the cluster that the
 // ingress controller is running on.
-func GetAddresses(p *store.PodInfo, kubeClient *kubernetes.Clientset) ([]string, error) {
+func GetAddresses(p *store.PodInfo, kubeClient kubernetes.Interface) ([]string, error) {
 	var addrs []string
 
 	// If not running inside a cluster, we currently don't report any addresses
@@ -42,6 +43,60 @@ func GetAddresses(p *store.PodInfo, kubeClient *kubernetes.Clientset) ([]string,
 	return addrs, nil
 }
 
+// revisionLabels are set by workload controllers on each revision of a pod. They are ignored
+// when searching for other replicas so that pods from a rolling update are taken into account.
+var revisionLabels = []string{
+	"pod-template-hash",
+	"controller-revision-hash",
+	"pod-template-generation",
+	"statefulset.kubernetes.io/pod-name",
+}
+
+// HasRunningPeers returns true if another ready replica of the ingress controller,
+// which is not being deleted, is running next to the current pod.
+func HasRunningPeers(p *store.PodInfo, kubeClient kubernetes.Interface) (bool, error) {
+	// Without labels, we cannot tell other replicas apart from unrelated pods
+	if p == nil || len(p.Labels) == 0 {
+		return false, nil
+	}
+
+	selector := labels.Set{}
+	for k, v := range p.Labels {
+		if !slices.Contains(revisionLabels, k) {
+			selector[k] = v
+		}
+	}
+
+	pods, err := kubeClient.CoreV1().Pods(p.Namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	for _, pod := range pods.Items {
+		if pod.Name == p.Name || pod.DeletionTimestamp != nil {
+			continue
+		}
+		if isPodReady(&pod) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func isPodReady(pod *apiv1.Pod) bool {
+	if pod.Status.Phase != apiv1.PodRunning {
+		return false
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == apiv1.PodReady {
+			return cond.Status == apiv1.ConditionTrue
+		}
+	}
+	return false
+}
+
 // Copied from https://github.com/kubernetes/kubernetes/pull/95179
 func isSubset(subSet, superSet labels.Set) bool {
 	if len(superSet) == 0 {
@@ -84,7 +139,7 @@ func GetAddressFromService(service *apiv1.Service) string {
 
 // GetPodDetails returns runtime information about the pod:
 // name, namespace and IP of the node where it is running
-func GetPodDetails(logger *zap.SugaredLogger, kubeClient *kubernetes.Clientset) (*store.PodInfo, error) {
+func GetPodDetails(logger *zap.SugaredLogger, kubeClient kubernetes.Interface) (*store.PodInfo, error) {
 	podName := os.Getenv("POD_NAME")
 	podNs := os.Getenv("POD_NAMESPACE")
 
@@ -105,6 +160,7 @@ func GetPodDetails(logger *zap.SugaredLogger, kubeClient *kubernetes.Clientset)
 	return &store.PodInfo{
 		Name:      podName,
 		Namespace: podNs,
+		UID:       pod.GetUID(),
 		Labels:    pod.GetLabels(),
 	}, nil
 }
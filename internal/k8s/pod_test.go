@@ -0,0 +1,115 @@
+package k8s
+
+import (
+	"testing"
+	"time"
+
+	"github.com/caddyserver/ingress/pkg/store"
+	"github.com/stretchr/testify/require"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func createPod(name string, labels map[string]string, ready bool) *apiv1.Pod {
+	status := apiv1.ConditionFalse
+	if ready {
+		status = apiv1.ConditionTrue
+	}
+	return &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "caddy-system", Labels: labels},
+		Status: apiv1.PodStatus{
+			Phase:      apiv1.PodRunning,
+			Conditions: []apiv1.PodCondition{{Type: apiv1.PodReady, Status: status}},
+		},
+	}
+}
+
+func TestHasRunningPeers(t *testing.T) {
+	current := &store.PodInfo{
+		Name:      "caddy-new",
+		Namespace: "caddy-system",
+		Labels:    map[string]string{"app": "caddy", "pod-template-hash": "new"},
+	}
+
+	terminating := createPod("caddy-old", map[string]string{"app": "caddy", "pod-template-hash": "old"}, true)
+	terminating.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+
+	testCases := []struct {
+		desc     string
+		pod      *store.PodInfo
+		pods     []*apiv1.Pod
+		expected bool
+	}{
+		{
+			desc:     "outside a cluster",
+			expected: false,
+		},
+		{
+			desc: "only the current pod",
+			pod:  current,
+			pods: []*apiv1.Pod{
+				createPod("caddy-new", current.Labels, true),
+			},
+			expected: false,
+		},
+		{
+			desc: "replica of the same revision",
+			pod:  current,
+			pods: []*apiv1.Pod{
+				createPod("caddy-new", current.Labels, true),
+				createPod("caddy-new-2", current.Labels, true),
+			},
+			expected: true,
+		},
+		{
+			desc: "replica of the previous revision during a rolling update",
+			pod:  current,
+			pods: []*apiv1.Pod{
+				createPod("caddy-new", current.Labels, true),
+				createPod("caddy-old", map[string]string{"app": "caddy", "pod-template-hash": "old"}, true),
+			},
+			expected: true,
+		},
+		{
+			desc: "replica not ready",
+			pod:  current,
+			pods: []*apiv1.Pod{
+				createPod("caddy-new", current.Labels, true),
+				createPod("caddy-old", map[string]string{"app": "caddy", "pod-template-hash": "old"}, false),
+			},
+			expected: false,
+		},
+		{
+			desc: "replica being deleted",
+			pod:  current,
+			pods: []*apiv1.Pod{
+				createPod("caddy-new", current.Labels, true),
+				terminating,
+			},
+			expected: false,
+		},
+		{
+			desc: "unrelated pod",
+			pod:  current,
+			pods: []*apiv1.Pod{
+				createPod("caddy-new", current.Labels, true),
+				createPod("nginx", map[string]string{"app": "nginx", "pod-template-hash": "new"}, true),
+			},
+			expected: false,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			client := fake.NewClientset()
+			for _, pod := range tC.pods {
+				require.NoError(t, client.Tracker().Add(pod))
+			}
+
+			hasPeers, err := HasRunningPeers(tC.pod, client)
+			require.NoError(t, err)
+			require.Equal(t, tC.expected, hasPeers)
+		})
+	}
+}
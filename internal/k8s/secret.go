@@ -0,0 +1,50 @@
+package k8s
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+type SecretHandlers struct {
+	AddFunc    func(obj *v1.Secret)
+	UpdateFunc func(oldObj, newObj *v1.Secret)
+	DeleteFunc func(obj *v1.Secret)
+}
+
+type SecretParams struct {
+	InformerFactory informers.SharedInformerFactory
+	SecretName      string
+}
+
+// WatchSecret registers handlers for the Secret of the factory with the given name.
+func WatchSecret(options SecretParams, funcs SecretHandlers) cache.SharedIndexInformer {
+	informer := options.InformerFactory.Core().V1().Secrets().Informer()
+
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj any) {
+			secret, ok := obj.(*v1.Secret)
+
+			if ok && secret.Name == options.SecretName {
+				funcs.AddFunc(secret)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj any) {
+			oldSecret, ok1 := oldObj.(*v1.Secret)
+			newSecret, ok2 := newObj.(*v1.Secret)
+
+			if ok1 && ok2 && newSecret.Name == options.SecretName {
+				funcs.UpdateFunc(oldSecret, newSecret)
+			}
+		},
+		DeleteFunc: func(obj any) {
+			secret, ok := obj.(*v1.Secret)
+
+			if ok && secret.Name == options.SecretName {
+				funcs.DeleteFunc(secret)
+			}
+		},
+	})
+
+	return informer
+}
@@ -0,0 +1,46 @@
+package k8s
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+type ServiceHandlers struct {
+	AddFunc    func(obj *apiv1.Service)
+	UpdateFunc func(oldObj, newObj *apiv1.Service)
+	DeleteFunc func(obj *apiv1.Service)
+}
+
+type ServiceParams struct {
+	InformerFactory informers.SharedInformerFactory
+}
+
+// WatchServices registers handlers for Service resources, used to resolve
+// the ports of ingress backends.
+func WatchServices(options ServiceParams, funcs ServiceHandlers) cache.SharedIndexInformer {
+	informer := options.InformerFactory.Core().V1().Services().Informer()
+
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj any) {
+			if svc, ok := obj.(*apiv1.Service); ok {
+				funcs.AddFunc(svc)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj any) {
+			oldSvc, ok1 := oldObj.(*apiv1.Service)
+			newSvc, ok2 := newObj.(*apiv1.Service)
+
+			if ok1 && ok2 {
+				funcs.UpdateFunc(oldSvc, newSvc)
+			}
+		},
+		DeleteFunc: func(obj any) {
+			if svc, ok := obj.(*apiv1.Service); ok {
+				funcs.DeleteFunc(svc)
+			}
+		},
+	})
+
+	return informer
+}
@@ -1,6 +1,10 @@
 package k8s
 
 import (
+	"crypto/tls"
+	"fmt"
+	"time"
+
 	v12 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/networking/v1"
 	"k8s.io/client-go/informers"
@@ -65,12 +69,36 @@ func ListTLSSecrets(options TLSSecretParams, ings []*v1.Ingress) ([]*v12.Secret,
 }
 
 func IsManagedTLSSecret(secret *v12.Secret, ings []*v1.Ingress) bool {
+	return len(IngressesUsingTLSSecret(secret, ings)) > 0
+}
+
+// IngressesUsingTLSSecret returns the ingresses referencing the secret in their TLS config.
+func IngressesUsingTLSSecret(secret *v12.Secret, ings []*v1.Ingress) []*v1.Ingress {
+	var using []*v1.Ingress
 	for _, ing := range ings {
 		for _, tlsRule := range ing.Spec.TLS {
 			if tlsRule.SecretName == secret.Name && ing.Namespace == secret.Namespace {
-				return true
+				using = append(using, ing)
+				break
 			}
 		}
 	}
-	return false
+	return using
+}
+
+// ValidateTLSSecret checks that the secret contains a usable certificate and private key,
+// and that the certificate is not expired at now.
+func ValidateTLSSecret(secret *v12.Secret, now time.Time) error {
+	if secret.Type != v12.SecretTypeTLS {
+		return fmt.Errorf("secret %s/%s is of type %q, expected %q", secret.Namespace, secret.Name, secret.Type, v12.SecretTypeTLS)
+	}
+
+	cert, err := tls.X509KeyPair(secret.Data[v12.TLSCertKey], secret.Data[v12.TLSPrivateKeyKey])
+	if err != nil {
+		return fmt.Errorf("secret %s/%s does not contain a valid certificate: %w", secret.Namespace, secret.Name, err)
+	}
+	if now.After(cert.Leaf.NotAfter) {
+		return fmt.Errorf("certificate of secret %s/%s expired on %s", secret.Namespace, secret.Name, cert.Leaf.NotAfter.Format(time.RFC3339))
+	}
+	return nil
 }
@@ -0,0 +1,102 @@
+package k8s
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// createTLSSecret returns a TLS secret with a self-signed certificate valid until notAfter.
+func createTLSSecret(t *testing.T, notAfter time.Time) *apiv1.Secret {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		DNSNames:     []string{"example.com"},
+		NotBefore:    notAfter.Add(-24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return &apiv1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "tls", Namespace: "default"},
+		Type:       apiv1.SecretTypeTLS,
+		Data: map[string][]byte{
+			apiv1.TLSCertKey:       pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			apiv1.TLSPrivateKeyKey: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+		},
+	}
+}
+
+func TestValidateTLSSecret(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		desc          string
+		secret        func() *apiv1.Secret
+		expectedError string
+	}{
+		{
+			desc:   "valid certificate",
+			secret: func() *apiv1.Secret { return createTLSSecret(t, now.Add(time.Hour)) },
+		},
+		{
+			desc: "not a TLS secret",
+			secret: func() *apiv1.Secret {
+				s := createTLSSecret(t, now.Add(time.Hour))
+				s.Type = apiv1.SecretTypeOpaque
+				return s
+			},
+			expectedError: `secret default/tls is of type "Opaque", expected "kubernetes.io/tls"`,
+		},
+		{
+			desc: "missing private key",
+			secret: func() *apiv1.Secret {
+				s := createTLSSecret(t, now.Add(time.Hour))
+				delete(s.Data, apiv1.TLSPrivateKeyKey)
+				return s
+			},
+			expectedError: "secret default/tls does not contain a valid certificate",
+		},
+		{
+			desc: "bad PEM",
+			secret: func() *apiv1.Secret {
+				s := createTLSSecret(t, now.Add(time.Hour))
+				s.Data[apiv1.TLSCertKey] = []byte("not a certificate")
+				return s
+			},
+			expectedError: "secret default/tls does not contain a valid certificate",
+		},
+		{
+			desc:          "expired certificate",
+			secret:        func() *apiv1.Secret { return createTLSSecret(t, now.Add(-time.Hour)) },
+			expectedError: "certificate of secret default/tls expired on 2024-05-31T23:00:00Z",
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			err := ValidateTLSSecret(tC.secret(), now)
+			if tC.expectedError == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tC.expectedError)
+		})
+	}
+}
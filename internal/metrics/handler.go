@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	caddy.RegisterModule(Handler{})
+}
+
+// Handler registers the controller metrics in the metrics registry of the loaded caddy
+// config, so that they are served by the caddy metrics handler that follows it.
+type Handler struct{}
+
+func (Handler) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID:  "http.handlers.controller_metrics",
+		New: func() caddy.Module { return new(Handler) },
+	}
+}
+
+// Provision registers the controller collectors.
+func (h *Handler) Provision(ctx caddy.Context) error {
+	registry := ctx.GetMetricsRegistry()
+	for _, c := range collectors() {
+		if err := registry.Register(c); err != nil {
+			are := prometheus.AlreadyRegisteredError{}
+			if !errors.As(err, &are) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request, next caddyhttp.Handler) error {
+	return next.ServeHTTP(w, r)
+}
+
+// Interface guards
+var (
+	_ caddy.Provisioner           = (*Handler)(nil)
+	_ caddyhttp.MiddlewareHandler = (*Handler)(nil)
+)
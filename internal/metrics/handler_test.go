@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+	_ "github.com/caddyserver/caddy/v2/modules/metrics"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// serveMetrics provisions the controller metrics route and returns the metrics it serves.
+func serveMetrics(t *testing.T) string {
+	ctx, cancel := caddy.NewContext(caddy.Context{Context: context.Background()})
+	defer cancel()
+
+	routes := caddyhttp.RouteList{{
+		HandlersRaw: []json.RawMessage{
+			json.RawMessage(`{ "handler": "controller_metrics" }`),
+			json.RawMessage(`{ "handler": "metrics" }`),
+		},
+	}}
+	require.NoError(t, routes.Provision(ctx))
+
+	rec := httptest.NewRecorder()
+	handler := routes.Compile(caddyhttp.HandlerFunc(func(http.ResponseWriter, *http.Request) error { return nil }))
+	require.NoError(t, handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	return rec.Body.String()
+}
+
+func TestHandlerExposesControllerMetrics(t *testing.T) {
+	queue := workqueue.NewTypedWithConfig(workqueue.TypedQueueConfig[string]{Name: "test"})
+	defer queue.ShutDown()
+	queue.Add("item")
+
+	ReloadsTotal.WithLabelValues("success").Inc()
+	ReloadDuration.Observe(0.1)
+	SecretStorageAPICallsTotal.WithLabelValues("get", "success").Inc()
+
+	body := serveMetrics(t)
+	require.Contains(t, body, `caddy_ingress_controller_workqueue_depth{name="test"} 1`)
+	require.Contains(t, body, `caddy_ingress_controller_workqueue_adds_total{name="test"} 1`)
+	require.Contains(t, body, `caddy_ingress_controller_reloads_total{result="success"}`)
+	require.Contains(t, body, "caddy_ingress_controller_reload_duration_seconds_count")
+	require.Contains(t, body, `caddy_ingress_controller_secret_storage_api_calls_total{operation="get",result="success"}`)
+}
+
+func TestHandlerProvisionsTwice(t *testing.T) {
+	// controller metrics are registered again on each config reload
+	serveMetrics(t)
+	require.Contains(t, serveMetrics(t), "caddy_ingress_controller_reload_duration_seconds_count")
+}
@@ -0,0 +1,126 @@
+// Package metrics holds the Prometheus collectors of the ingress controller.
+// They are served next to caddy metrics by the controller_metrics handler.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "caddy_ingress_controller"
+
+var (
+	ConversionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "conversions_total",
+		Help:      "Number of conversions of cluster resources to a caddy config by result.",
+	}, []string{"result"})
+
+	ConversionDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "conversion_duration_seconds",
+		Help:      "Time spent converting cluster resources to a caddy config.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	IngressConversionErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ingress_conversion_errors_total",
+		Help:      "Number of Ingress conversion errors by plugin.",
+	}, []string{"plugin"})
+
+	ReloadsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reloads_total",
+		Help:      "Number of caddy config reloads by result.",
+	}, []string{"result"})
+
+	ReloadDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "reload_duration_seconds",
+		Help:      "Time spent loading a new caddy config.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	ConfigSize = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "config_size_bytes",
+		Help:      "Size of the last generated caddy config.",
+	})
+
+	ManagedIngresses = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "managed_ingresses",
+		Help:      "Number of Ingresses managed by the controller.",
+	})
+
+	ManagedHosts = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "managed_hosts",
+		Help:      "Number of distinct hosts in managed Ingresses.",
+	})
+
+	ManagedRoutes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "managed_routes",
+		Help:      "Number of paths in managed Ingresses.",
+	})
+
+	ActionsDroppedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "actions_dropped_total",
+		Help:      "Number of actions dropped after exhausting their retries.",
+	}, []string{"action"})
+
+	StatusUpdateFailuresTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "status_update_failures_total",
+		Help:      "Number of failed Ingress status updates.",
+	})
+
+	SecretStorageAPICallsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "secret_storage_api_calls_total",
+		Help:      "Number of Kubernetes API calls made by the secret storage by operation and result.",
+	}, []string{"operation", "result"})
+
+	SecretStorageLockWait = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "secret_storage_lock_wait_seconds",
+		Help:      "Time spent waiting to acquire a secret storage lock.",
+		Buckets:   []float64{.01, .1, .5, 1, 5, 10, 30, 60, 120, 300},
+	})
+)
+
+// collectors returns all the controller collectors, including workqueue ones.
+func collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		ConversionsTotal,
+		ConversionDuration,
+		IngressConversionErrorsTotal,
+		ReloadsTotal,
+		ReloadDuration,
+		ConfigSize,
+		ManagedIngresses,
+		ManagedHosts,
+		ManagedRoutes,
+		ActionsDroppedTotal,
+		StatusUpdateFailuresTotal,
+		SecretStorageAPICallsTotal,
+		SecretStorageLockWait,
+		workqueueDepth,
+		workqueueAdds,
+		workqueueLatency,
+		workqueueWorkDuration,
+		workqueueUnfinishedWork,
+		workqueueLongestRunningProcessor,
+		workqueueRetries,
+	}
+}
+
+// Result returns the result label of an operation.
+func Result(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
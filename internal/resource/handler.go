@@ -0,0 +1,100 @@
+package resource
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+)
+
+func init() {
+	caddy.RegisterModule(Handler{})
+}
+
+// Handler serves the files of the keys of a ConfigMap of a Resource backend.
+//
+// Keys are flat file names, so the last segment of the request path selects the file, and
+// paths ending with a slash get the index file. With the SPA fallback, requests that match no
+// file get the index file instead of a 404.
+//
+// The caddy file server is not used as it depends on the markdown and syntax highlighting
+// libraries of the templates module, for features Resource backends do not need.
+type Handler struct {
+	// Root is the directory holding the files of the ConfigMap.
+	Root string `json:"root"`
+
+	// Index is the file served for directory requests.
+	Index string `json:"index"`
+
+	// SPAFallback serves the index file for requests that match no file.
+	SPAFallback bool `json:"spa_fallback,omitempty"`
+}
+
+func (Handler) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID:  "http.handlers.ingress_resource",
+		New: func() caddy.Module { return new(Handler) },
+	}
+}
+
+func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request, _ caddyhttp.Handler) error {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		return caddyhttp.Error(http.StatusMethodNotAllowed, nil)
+	}
+
+	name := h.Index
+	if !strings.HasSuffix(r.URL.Path, "/") {
+		name = path.Base(r.URL.Path)
+	}
+
+	f, info, err := h.open(name)
+	if errors.Is(err, fs.ErrNotExist) && h.SPAFallback && name != h.Index {
+		f, info, err = h.open(h.Index)
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return caddyhttp.Error(http.StatusNotFound, nil)
+	}
+	if err != nil {
+		return caddyhttp.Error(http.StatusInternalServerError, err)
+	}
+	defer f.Close()
+
+	// sets the content type from the extension or the content, and handles
+	// conditional and range requests
+	http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+	return nil
+}
+
+// open opens a file of the root directory. Hidden files, like the temporary files
+// of ConfigMaps being written, do not exist.
+func (h Handler) open(name string) (*os.File, fs.FileInfo, error) {
+	if name == "" || strings.HasPrefix(name, ".") || strings.ContainsAny(name, `/\`) {
+		return nil, nil, fs.ErrNotExist
+	}
+
+	f, err := os.Open(filepath.Join(h.Root, name))
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err == nil && info.IsDir() {
+		err = fs.ErrNotExist
+	}
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, info, nil
+}
+
+// Interface guards
+var (
+	_ caddyhttp.MiddlewareHandler = (*Handler)(nil)
+)
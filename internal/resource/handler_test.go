@@ -0,0 +1,107 @@
+package resource
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerServesFiles(t *testing.T) {
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"index.html": "<h1>Down for maintenance</h1>",
+		"robots.txt": "User-agent: *\nDisallow: /\n",
+		".tmp-123":   "partially written",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte(content), 0644))
+	}
+
+	testCases := []struct {
+		desc                string
+		spaFallback         bool
+		method              string
+		path                string
+		expectedStatus      int
+		expectedBody        string
+		expectedContentType string
+	}{
+		{
+			desc:                "file",
+			path:                "/robots.txt",
+			expectedStatus:      http.StatusOK,
+			expectedBody:        "User-agent: *\nDisallow: /\n",
+			expectedContentType: "text/plain; charset=utf-8",
+		},
+		{
+			desc:                "file in a sub path",
+			path:                "/static/robots.txt",
+			expectedStatus:      http.StatusOK,
+			expectedBody:        "User-agent: *\nDisallow: /\n",
+			expectedContentType: "text/plain; charset=utf-8",
+		},
+		{
+			desc:                "directory",
+			path:                "/docs/",
+			expectedStatus:      http.StatusOK,
+			expectedBody:        "<h1>Down for maintenance</h1>",
+			expectedContentType: "text/html; charset=utf-8",
+		},
+		{
+			desc:           "unknown file",
+			path:           "/app.js",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			desc:                "unknown file with SPA fallback",
+			spaFallback:         true,
+			path:                "/users/42",
+			expectedStatus:      http.StatusOK,
+			expectedBody:        "<h1>Down for maintenance</h1>",
+			expectedContentType: "text/html; charset=utf-8",
+		},
+		{
+			desc:           "file being written",
+			path:           "/.tmp-123",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			desc:           "path traversal",
+			path:           "/..",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			desc:           "unsupported method",
+			method:         http.MethodPost,
+			path:           "/robots.txt",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			h := Handler{Root: root, Index: "index.html", SPAFallback: tC.spaFallback}
+			method := tC.method
+			if method == "" {
+				method = http.MethodGet
+			}
+
+			w := httptest.NewRecorder()
+			err := h.ServeHTTP(w, httptest.NewRequest(method, tC.path, nil), nil)
+			if tC.expectedStatus != http.StatusOK {
+				var handlerErr caddyhttp.HandlerError
+				require.ErrorAs(t, err, &handlerErr)
+				require.Equal(t, tC.expectedStatus, handlerErr.StatusCode)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tC.expectedStatus, w.Code)
+			require.Equal(t, tC.expectedBody, w.Body.String())
+			require.Equal(t, tC.expectedContentType, w.Header().Get("Content-Type"))
+		})
+	}
+}
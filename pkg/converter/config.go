@@ -4,6 +4,7 @@ import (
 	"github.com/caddyserver/caddy/v2"
 	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
 	"github.com/caddyserver/caddy/v2/modules/caddytls"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 // StorageValues represents the config for certmagic storage providers.
@@ -18,12 +19,20 @@ type Storage struct {
 	StorageValues
 }
 
+// IngressRoutes are the routes generated for each ingress, by UID.
+type IngressRoutes map[types.UID]caddyhttp.RouteList
+
 // Config represents a caddy2 config file.
 type Config struct {
 	Admin   caddy.AdminConfig `json:"admin,omitempty"`
 	Storage Storage           `json:"storage"`
 	Apps    map[string]any    `json:"apps"`
 	Logging caddy.Logging     `json:"logging"`
+
+	// IngressRoutes are the routes served for each ingress by this config. It starts with the
+	// last good routes of the previous conversion, which are replaced for each ingress that
+	// converts successfully.
+	IngressRoutes IngressRoutes `json:"-"`
 }
 
 func (c Config) GetHTTPServer() *caddyhttp.Server {
@@ -40,7 +49,8 @@ func (c Config) GetTLSApp() *caddytls.TLS {
 
 func NewConfig() *Config {
 	return &Config{
-		Logging: caddy.Logging{},
+		Logging:       caddy.Logging{},
+		IngressRoutes: IngressRoutes{},
 		Apps: map[string]any{
 			"tls": &caddytls.TLS{CertificatesRaw: caddy.ModuleMap{}},
 			"http": &caddyhttp.App{
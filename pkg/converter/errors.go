@@ -0,0 +1,59 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+
+	v1 "k8s.io/api/networking/v1"
+)
+
+// IngressError is returned when the conversion of a specific Ingress failed.
+// Such an error is not fatal: the Ingress is left out of the generated config
+// (or keeps its last working routes) while the rest of the config can be loaded.
+type IngressError struct {
+	Ingress *v1.Ingress
+	Host    string
+	Path    string
+	Plugin  string
+	Err     error
+}
+
+func (e *IngressError) Error() string {
+	return fmt.Sprintf(
+		"ingress %s/%s (host: %q, path: %q, plugin: %s): %v",
+		e.Ingress.Namespace, e.Ingress.Name, e.Host, e.Path, e.Plugin, e.Err,
+	)
+}
+
+func (e *IngressError) Unwrap() error {
+	return e.Err
+}
+
+// SplitIngressErrors separates errors attributed to a specific Ingress from other errors.
+// err can be a single error or a tree of errors created with errors.Join.
+// The returned error is nil when all errors are IngressError.
+func SplitIngressErrors(err error) ([]*IngressError, error) {
+	if err == nil {
+		return nil, nil
+	}
+
+	if ingErr, ok := err.(*IngressError); ok {
+		return []*IngressError{ingErr}, nil
+	}
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return nil, err
+	}
+
+	var ingErrs []*IngressError
+	var others []error
+	for _, e := range joined.Unwrap() {
+		i, o := SplitIngressErrors(e)
+		ingErrs = append(ingErrs, i...)
+		if o != nil {
+			others = append(others, o)
+		}
+	}
+	return ingErrs, errors.Join(others...)
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/caddyserver/caddy/v2"
 	"github.com/caddyserver/certmagic"
+	"github.com/caddyserver/ingress/internal/metrics"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
@@ -45,6 +46,14 @@ func cleanKey(key string, prefix string) string {
 	return prefix + specialChars.ReplaceAllString(key, ".")
 }
 
+// observeAPICall counts a Kubernetes API call, not found errors are expected results.
+func observeAPICall(operation string, err error) {
+	if errors.IsNotFound(err) {
+		err = nil
+	}
+	metrics.SecretStorageAPICallsTotal.WithLabelValues(operation, metrics.Result(err)).Inc()
+}
+
 // SecretStorage facilitates storing certificates retrieved by certmagic in kubernetes secrets.
 type SecretStorage struct {
 	Namespace string
@@ -90,6 +99,7 @@ func (s *SecretStorage) Exists(ctx context.Context, key string) bool {
 	secrets, err := s.kubeClient.CoreV1().Secrets(s.Namespace).List(context.TODO(), metav1.ListOptions{
 		FieldSelector: fmt.Sprintf("metadata.name=%v", cleanKey(key, keyPrefix)),
 	})
+	observeAPICall("list", err)
 
 	if err != nil {
 		return false
@@ -122,9 +132,11 @@ func (s *SecretStorage) Store(ctx context.Context, key string, value []byte) err
 	if s.Exists(ctx, key) {
 		s.logger.Debug("creating secret", zap.String("name", key))
 		_, err = s.kubeClient.CoreV1().Secrets(s.Namespace).Update(context.TODO(), &se, metav1.UpdateOptions{})
+		observeAPICall("update", err)
 	} else {
 		s.logger.Debug("updating secret", zap.String("name", key))
 		_, err = s.kubeClient.CoreV1().Secrets(s.Namespace).Create(context.TODO(), &se, metav1.CreateOptions{})
+		observeAPICall("create", err)
 	}
 
 	if err != nil {
@@ -137,6 +149,7 @@ func (s *SecretStorage) Store(ctx context.Context, key string, value []byte) err
 // Load retrieves the value at the given key.
 func (s *SecretStorage) Load(ctx context.Context, key string) ([]byte, error) {
 	secret, err := s.kubeClient.CoreV1().Secrets(s.Namespace).Get(context.TODO(), cleanKey(key, keyPrefix), metav1.GetOptions{})
+	observeAPICall("get", err)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			return nil, fs.ErrNotExist
@@ -151,6 +164,7 @@ func (s *SecretStorage) Load(ctx context.Context, key string) ([]byte, error) {
 // Delete deletes the value at the given key.
 func (s *SecretStorage) Delete(ctx context.Context, key string) error {
 	err := s.kubeClient.CoreV1().Secrets(s.Namespace).Delete(context.TODO(), cleanKey(key, keyPrefix), metav1.DeleteOptions{})
+	observeAPICall("delete", err)
 	if err != nil {
 		return err
 	}
@@ -167,6 +181,7 @@ func (s *SecretStorage) List(ctx context.Context, prefix string, recursive bool)
 	secrets, err := s.kubeClient.CoreV1().Secrets(s.Namespace).List(context.TODO(), metav1.ListOptions{
 		LabelSelector: labels.SelectorFromSet(matchLabels).String(),
 	})
+	observeAPICall("list", err)
 	if err != nil {
 		return keys, err
 	}
@@ -185,6 +200,7 @@ func (s *SecretStorage) List(ctx context.Context, prefix string, recursive bool)
 // Stat returns information about key.
 func (s *SecretStorage) Stat(ctx context.Context, key string) (certmagic.KeyInfo, error) {
 	secret, err := s.kubeClient.CoreV1().Secrets(s.Namespace).Get(context.TODO(), cleanKey(key, keyPrefix), metav1.GetOptions{})
+	observeAPICall("get", err)
 	if err != nil {
 		return certmagic.KeyInfo{}, err
 	}
@@ -200,9 +216,11 @@ func (s *SecretStorage) Stat(ctx context.Context, key string) (certmagic.KeyInfo
 }
 
 func (s *SecretStorage) Lock(ctx context.Context, key string) error {
+	start := time.Now()
 	for {
 		_, err := s.tryAcquireOrRenew(ctx, cleanKey(key, leasePrefix), false)
 		if err == nil {
+			metrics.SecretStorageLockWait.Observe(time.Since(start).Seconds())
 			go s.keepLockUpdated(ctx, cleanKey(key, leasePrefix))
 			return nil
 		}
@@ -210,6 +228,7 @@ func (s *SecretStorage) Lock(ctx context.Context, key string) error {
 		select {
 		case <-time.After(leasePollInterval):
 		case <-ctx.Done():
+			metrics.SecretStorageLockWait.Observe(time.Since(start).Seconds())
 			return ctx.Err()
 		}
 	}
@@ -249,6 +268,7 @@ func (s *SecretStorage) tryAcquireOrRenew(ctx context.Context, key string, shoul
 	}
 
 	currLer, _, err := lock.Get(ctx)
+	observeAPICall("lease_get", err)
 
 	// 1. obtain or create the ElectionRecord
 	if err != nil {
@@ -258,7 +278,9 @@ func (s *SecretStorage) tryAcquireOrRenew(ctx context.Context, key string, shoul
 		if shouldExist {
 			return true, nil // Lock has been released
 		}
-		if err = lock.Create(ctx, ler); err != nil {
+		err = lock.Create(ctx, ler)
+		observeAPICall("lease_create", err)
+		if err != nil {
 			return true, err
 		}
 		return false, nil
@@ -279,7 +301,9 @@ func (s *SecretStorage) tryAcquireOrRenew(ctx context.Context, key string, shoul
 		ler.LeaderTransitions = currLer.LeaderTransitions + 1
 	}
 
-	if err = lock.Update(ctx, ler); err != nil {
+	err = lock.Update(ctx, ler)
+	observeAPICall("lease_update", err)
+	if err != nil {
 		return true, fmt.Errorf("failed to update lock: %v", err)
 	}
 	return false, nil
@@ -287,5 +311,6 @@ func (s *SecretStorage) tryAcquireOrRenew(ctx context.Context, key string, shoul
 
 func (s *SecretStorage) Unlock(ctx context.Context, key string) error {
 	err := s.kubeClient.CoordinationV1().Leases(s.Namespace).Delete(context.TODO(), cleanKey(key, leasePrefix), metav1.DeleteOptions{})
+	observeAPICall("lease_delete", err)
 	return err
 }
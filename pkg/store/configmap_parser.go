@@ -2,27 +2,48 @@ package store
 
 import (
 	"fmt"
+	"math"
 	"reflect"
 	"time"
 
 	"github.com/caddyserver/caddy/v2"
+	"github.com/dustin/go-humanize"
 	"github.com/mitchellh/mapstructure"
 	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 // ConfigMapOptions represents global options set through a configmap
 type ConfigMapOptions struct {
-	Debug                 bool           `json:"debug,omitempty"`
-	AcmeCA                string         `json:"acmeCA,omitempty"`
-	AcmeEABKeyID          string         `json:"acmeEABKeyId,omitempty"`
-	AcmeEABMacKey         string         `json:"acmeEABMacKey,omitempty"`
-	Email                 string         `json:"email,omitempty"`
-	ExperimentalSmartSort bool           `json:"experimentalSmartSort,omitempty"`
-	ProxyProtocol         bool           `json:"proxyProtocol,omitempty"`
-	Metrics               bool           `json:"metrics,omitempty"`
-	OnDemandTLS           bool           `json:"onDemandTLS,omitempty"`
-	OnDemandAsk           string         `json:"onDemandAsk,omitempty"`
-	OCSPCheckInterval     caddy.Duration `json:"ocspCheckInterval,omitempty"`
+	Debug                      bool           `json:"debug,omitempty"`
+	AcmeCA                     string         `json:"acmeCA,omitempty"`
+	AcmeEABKeyID               string         `json:"acmeEABKeyId,omitempty"`
+	AcmeEABMacKey              string         `json:"acmeEABMacKey,omitempty"`
+	Email                      string         `json:"email,omitempty"`
+	ExperimentalSmartSort      bool           `json:"experimentalSmartSort,omitempty"`
+	ProxyProtocol              bool           `json:"proxyProtocol,omitempty"`
+	Metrics                    bool           `json:"metrics,omitempty"`
+	OnDemandTLS                bool           `json:"onDemandTLS,omitempty"`
+	OnDemandAsk                string         `json:"onDemandAsk,omitempty"`
+	OCSPCheckInterval          caddy.Duration `json:"ocspCheckInterval,omitempty"`
+	DebugEndpoints             bool           `json:"debugEndpoints,omitempty"`
+	ReadinessCheckCertificates bool           `json:"readinessCheckCertificates,omitempty"`
+	IngressLabelSelector       string         `json:"ingressLabelSelector,omitempty"`
+	PodEndpoints               bool           `json:"podEndpoints,omitempty"`
+	DefaultBackendService      string         `json:"defaultBackendService,omitempty"`
+	ClusterDomain              string         `json:"clusterDomain,omitempty"`
+	UpstreamAddressing         string         `json:"upstreamAddressing,omitempty"`
+	UpstreamIPFamily           string         `json:"upstreamIPFamily,omitempty"`
+	ProxyDialTimeout           caddy.Duration `json:"proxyDialTimeout,omitempty"`
+	ProxyResponseHeaderTimeout caddy.Duration `json:"proxyResponseHeaderTimeout,omitempty"`
+	ProxyReadTimeout           caddy.Duration `json:"proxyReadTimeout,omitempty"`
+	ProxyWriteTimeout          caddy.Duration `json:"proxyWriteTimeout,omitempty"`
+	ProxyKeepAliveIdleTimeout  caddy.Duration `json:"proxyKeepAliveIdleTimeout,omitempty"`
+	ProxyKeepAliveMaxIdleConns int            `json:"proxyKeepAliveMaxIdleConns,omitempty"`
+	ProxyRequestBuffers        string         `json:"proxyRequestBuffers,omitempty"`
+	ProxyResponseBuffers       string         `json:"proxyResponseBuffers,omitempty"`
+	ProxyMaxBodySize           string         `json:"proxyMaxBodySize,omitempty"`
+	ProxyFlushInterval         string         `json:"proxyFlushInterval,omitempty"`
 }
 
 func stringToCaddyDurationHookFunc() mapstructure.DecodeHookFunc {
@@ -33,6 +54,10 @@ func stringToCaddyDurationHookFunc() mapstructure.DecodeHookFunc {
 		if t != reflect.TypeOf(caddy.Duration(time.Second)) {
 			return data, nil
 		}
+		// empty values of the chart keep the default
+		if data.(string) == "" {
+			return caddy.Duration(0), nil
+		}
 		return caddy.ParseDuration(data.(string))
 	}
 }
@@ -59,5 +84,87 @@ func ParseConfigMap(cm *apiv1.ConfigMap) (*ConfigMapOptions, error) {
 		return nil, fmt.Errorf("unexpected error parsing configmap: %w", err)
 	}
 
+	if _, err := labels.Parse(cfgMap.IngressLabelSelector); err != nil {
+		return nil, fmt.Errorf("invalid ingressLabelSelector: %w", err)
+	}
+
+	// without ask endpoint, anyone pointing a domain to caddy gets it a certificate
+	if cfgMap.OnDemandTLS && cfgMap.OnDemandAsk == "" {
+		return nil, fmt.Errorf("onDemandAsk is required with onDemandTLS")
+	}
+
+	if cfgMap.DefaultBackendService != "" {
+		if _, _, err := ParseServiceBackend(cfgMap.DefaultBackendService); err != nil {
+			return nil, fmt.Errorf("invalid defaultBackendService: %w", err)
+		}
+	}
+
+	switch cfgMap.UpstreamAddressing {
+	case "", UpstreamAddressingDNS, UpstreamAddressingClusterIP, UpstreamAddressingEndpoints:
+	default:
+		return nil, fmt.Errorf("invalid upstreamAddressing %q, expected %q, %q or %q", cfgMap.UpstreamAddressing,
+			UpstreamAddressingDNS, UpstreamAddressingClusterIP, UpstreamAddressingEndpoints)
+	}
+
+	switch apiv1.IPFamily(cfgMap.UpstreamIPFamily) {
+	case "", apiv1.IPv4Protocol, apiv1.IPv6Protocol:
+	default:
+		return nil, fmt.Errorf("invalid upstreamIPFamily %q, expected %q or %q", cfgMap.UpstreamIPFamily,
+			apiv1.IPv4Protocol, apiv1.IPv6Protocol)
+	}
+
+	if cfgMap.ProxyKeepAliveMaxIdleConns < 0 {
+		return nil, fmt.Errorf("invalid proxyKeepAliveMaxIdleConns %d, expected a positive number", cfgMap.ProxyKeepAliveMaxIdleConns)
+	}
+	if _, err := ParseBufferSize(cfgMap.ProxyRequestBuffers); err != nil {
+		return nil, fmt.Errorf("invalid proxyRequestBuffers %q: %w", cfgMap.ProxyRequestBuffers, err)
+	}
+	if _, err := ParseBufferSize(cfgMap.ProxyResponseBuffers); err != nil {
+		return nil, fmt.Errorf("invalid proxyResponseBuffers %q: %w", cfgMap.ProxyResponseBuffers, err)
+	}
+	if _, err := ParseByteSize(cfgMap.ProxyMaxBodySize); err != nil {
+		return nil, fmt.Errorf("invalid proxyMaxBodySize %q: %w", cfgMap.ProxyMaxBodySize, err)
+	}
+	if _, err := ParseFlushInterval(cfgMap.ProxyFlushInterval); err != nil {
+		return nil, fmt.Errorf("invalid proxyFlushInterval %q: %w", cfgMap.ProxyFlushInterval, err)
+	}
+
 	return &cfgMap, nil
 }
+
+// ParseByteSize parses a size in bytes like 10MB, an empty size being 0.
+func ParseByteSize(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	size, err := humanize.ParseBytes(s)
+	if err != nil {
+		return 0, err
+	}
+	if size > math.MaxInt64 {
+		return 0, fmt.Errorf("size too large")
+	}
+	return int64(size), nil
+}
+
+// ParseBufferSize parses the size of the buffers of proxied requests or responses,
+// "unlimited" being -1 like in caddy.
+func ParseBufferSize(s string) (int64, error) {
+	if s == "unlimited" {
+		return -1, nil
+	}
+	return ParseByteSize(s)
+}
+
+// ParseFlushInterval parses the interval between flushes of proxied responses,
+// "-1" flushing immediately like in caddy.
+func ParseFlushInterval(s string) (caddy.Duration, error) {
+	switch s {
+	case "":
+		return 0, nil
+	case "-1":
+		return -1, nil
+	}
+	d, err := caddy.ParseDuration(s)
+	return caddy.Duration(d), err
+}
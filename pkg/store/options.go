@@ -1,12 +1,25 @@
 package store
 
+import "time"
+
 // Options represents ingress controller config received through cli arguments.
 type Options struct {
-	WatchNamespace    string
-	ConfigMapName     string
-	ClassName         string
-	ClassNameRequired bool
-	Verbose           bool
-	LeaseID           string
-	PluginsOrder      []string
+	WatchNamespaces       []string
+	NamespaceSelector     string
+	IngressLabelSelector  string
+	DefaultBackendService string
+	ConfigMapName         string
+	ClassName             string
+	ClassNameRequired     bool
+	ControllerName        string
+	Verbose               bool
+	LeaseID               string
+	LeaderElectionID      string
+	PluginsOrder          []string
+	MaxRetries            int
+	ReloadDebounce        time.Duration
+	ReloadMaxDelay        time.Duration
+	ConfigHistorySize     int
+	ConfigHistoryName     string
+	StuckWorkerTimeout    time.Duration
 }
@@ -1,9 +1,12 @@
 package store
 
+import "k8s.io/apimachinery/pkg/types"
+
 // PodInfo contains runtime information about the pod running the Ingress controller
 type PodInfo struct {
 	Name      string
 	Namespace string
+	UID       types.UID
 	// Labels selectors of the running pod
 	// This is used to search for other Ingress controller pods
 	Labels map[string]string
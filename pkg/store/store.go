@@ -1,7 +1,37 @@
 package store
 
 import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// PodEndpointsAnnotation overrides the podEndpoints and upstreamAddressing global options
+	// for an ingress.
+	PodEndpointsAnnotation = "caddy.ingress.kubernetes.io/pod-endpoints"
+
+	// DefaultClusterDomain is the DNS domain of the cluster when none is configured.
+	DefaultClusterDomain = "cluster.local"
+
+	// DefaultBackendName is the name of the ingress returned by Store.DefaultBackend.
+	DefaultBackendName = "default-backend"
+)
+
+// Upstream addressing modes.
+const (
+	// UpstreamAddressingDNS dials the cluster DNS name of services.
+	UpstreamAddressingDNS = "dns"
+	// UpstreamAddressingClusterIP dials the cluster IP of services.
+	UpstreamAddressingClusterIP = "clusterip"
+	// UpstreamAddressingEndpoints dials the pods of services.
+	UpstreamAddressingEndpoints = "endpoints"
 )
 
 // Store contains resources used to generate Caddy config
@@ -11,6 +41,15 @@ type Store struct {
 	ConfigMap       *ConfigMapOptions
 	ConfigNamespace string
 	CurrentPod      *PodInfo
+
+	// Services referenced by ingresses
+	Services []*apiv1.Service
+
+	// EndpointSlices of the services referenced by ingresses routing to pods
+	EndpointSlices []*discoveryv1.EndpointSlice
+
+	// ConfigMaps served by Resource backends
+	ResourceConfigMaps []*apiv1.ConfigMap
 }
 
 // NewStore returns a new store that keeps track of K8S resources needed by the controller.
@@ -21,6 +60,10 @@ func NewStore(opts Options, configNamespace string, podInfo *PodInfo) *Store {
 		ConfigMap:       &ConfigMapOptions{},
 		ConfigNamespace: configNamespace,
 		CurrentPod:      podInfo,
+
+		Services:           []*apiv1.Service{},
+		EndpointSlices:     []*discoveryv1.EndpointSlice{},
+		ResourceConfigMaps: []*apiv1.ConfigMap{},
 	}
 	return s
 }
@@ -72,3 +115,291 @@ func (s *Store) HasManagedTLS() bool {
 	}
 	return false
 }
+
+// RoutesToPods returns whether the ingress routes to the endpoints of its services
+// instead of the services themselves.
+func (s *Store) RoutesToPods(ing *v1.Ingress) bool {
+	return s.UpstreamAddressing(ing) == UpstreamAddressingEndpoints
+}
+
+// UpstreamAddressing returns how the ingress addresses the upstreams of its services.
+// The pod endpoints annotation overrides the global option.
+func (s *Store) UpstreamAddressing(ing *v1.Ingress) string {
+	addressing := UpstreamAddressingDNS
+	if s.ConfigMap != nil {
+		if s.ConfigMap.UpstreamAddressing != "" {
+			addressing = s.ConfigMap.UpstreamAddressing
+		} else if s.ConfigMap.PodEndpoints {
+			addressing = UpstreamAddressingEndpoints
+		}
+	}
+
+	switch ing.Annotations[PodEndpointsAnnotation] {
+	case "true":
+		return UpstreamAddressingEndpoints
+	case "false":
+		if addressing == UpstreamAddressingEndpoints {
+			return UpstreamAddressingDNS
+		}
+	}
+	return addressing
+}
+
+// ClusterDomain returns the DNS domain of the cluster.
+func (s *Store) ClusterDomain() string {
+	if s.ConfigMap != nil && s.ConfigMap.ClusterDomain != "" {
+		return s.ConfigMap.ClusterDomain
+	}
+	return DefaultClusterDomain
+}
+
+// UpstreamIPFamily returns the IP family used to dial cluster IPs and endpoints, or an empty
+// string to use the primary family of each service.
+func (s *Store) UpstreamIPFamily() apiv1.IPFamily {
+	if s.ConfigMap == nil {
+		return ""
+	}
+	return apiv1.IPFamily(s.ConfigMap.UpstreamIPFamily)
+}
+
+// DefaultBackend returns an ingress with the controller-wide default backend as default backend,
+// or nil if there is none. It is not an actual resource, it lets the controller-wide default backend
+// be handled like the default backend of an ingress.
+func (s *Store) DefaultBackend() *v1.Ingress {
+	ref := s.Options.DefaultBackendService
+	if s.ConfigMap != nil && s.ConfigMap.DefaultBackendService != "" {
+		ref = s.ConfigMap.DefaultBackendService
+	}
+	if ref == "" {
+		return nil
+	}
+
+	// the reference is validated when options are parsed
+	namespace, backend, err := ParseServiceBackend(ref)
+	if err != nil {
+		return nil
+	}
+	return &v1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: DefaultBackendName, Namespace: namespace},
+		Spec:       v1.IngressSpec{DefaultBackend: &v1.IngressBackend{Service: backend}},
+	}
+}
+
+// ParseServiceBackend parses a service reference in the namespace/name:port format,
+// where port is a port number or name.
+func ParseServiceBackend(ref string) (string, *v1.IngressServiceBackend, error) {
+	namespace, nameAndPort, ok := strings.Cut(ref, "/")
+	if !ok || namespace == "" {
+		return "", nil, fmt.Errorf("invalid service %q, expected namespace/name:port", ref)
+	}
+	name, port, ok := strings.Cut(nameAndPort, ":")
+	if !ok || name == "" || port == "" {
+		return "", nil, fmt.Errorf("invalid service %q, expected namespace/name:port", ref)
+	}
+
+	backend := &v1.IngressServiceBackend{Name: name}
+	if number, err := strconv.ParseInt(port, 10, 32); err == nil {
+		backend.Port.Number = int32(number)
+	} else {
+		backend.Port.Name = port
+	}
+	return namespace, backend, nil
+}
+
+// backendIngresses returns the ingresses and the controller-wide default backend.
+func (s *Store) backendIngresses() []*v1.Ingress {
+	if def := s.DefaultBackend(); def != nil {
+		return append(slices.Clone(s.Ingresses), def)
+	}
+	return s.Ingresses
+}
+
+// HasPodRouting returns whether at least one ingress routes to pods.
+func (s *Store) HasPodRouting() bool {
+	for _, ing := range s.backendIngresses() {
+		if s.RoutesToPods(ing) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsReferencedService returns whether an ingress references the service.
+func (s *Store) IsReferencedService(namespace, name string) bool {
+	for _, ing := range s.backendIngresses() {
+		if ing.Namespace == namespace && referencesService(ing, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPodRoutedService returns whether an ingress routing to pods references the service.
+func (s *Store) IsPodRoutedService(namespace, name string) bool {
+	for _, ing := range s.backendIngresses() {
+		if ing.Namespace == namespace && s.RoutesToPods(ing) && referencesService(ing, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// referencesService returns whether the ingress has a backend pointing to the service.
+func referencesService(ing *v1.Ingress, name string) bool {
+	for _, backend := range ingressBackends(ing) {
+		if backend.Service != nil && backend.Service.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ingressBackends returns the backends of the paths of an ingress, and its default backend.
+func ingressBackends(ing *v1.Ingress) []v1.IngressBackend {
+	var backends []v1.IngressBackend
+	if ing.Spec.DefaultBackend != nil {
+		backends = append(backends, *ing.Spec.DefaultBackend)
+	}
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			backends = append(backends, path.Backend)
+		}
+	}
+	return backends
+}
+
+// ResourceConfigMapName returns the name of the ConfigMap of a Resource backend,
+// or false if the backend is not a ConfigMap.
+func ResourceConfigMapName(backend v1.IngressBackend) (string, bool) {
+	res := backend.Resource
+	if res == nil || (res.APIGroup != nil && *res.APIGroup != "") || res.Kind != "ConfigMap" {
+		return "", false
+	}
+	return res.Name, true
+}
+
+// ResourceConfigMapKeys returns the namespace/name keys of the ConfigMaps served by ingresses, sorted.
+func (s *Store) ResourceConfigMapKeys() []string {
+	var keys []string
+	for _, ing := range s.Ingresses {
+		for _, backend := range ingressBackends(ing) {
+			if name, ok := ResourceConfigMapName(backend); ok {
+				keys = append(keys, ing.Namespace+"/"+name)
+			}
+		}
+	}
+	slices.Sort(keys)
+	return slices.Compact(keys)
+}
+
+// IsResourceConfigMap returns whether an ingress serves the content of the ConfigMap.
+func (s *Store) IsResourceConfigMap(namespace, name string) bool {
+	for _, ing := range s.Ingresses {
+		if ing.Namespace != namespace {
+			continue
+		}
+		for _, backend := range ingressBackends(ing) {
+			if cmName, ok := ResourceConfigMapName(backend); ok && cmName == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AddResourceConfigMap adds a ConfigMap served by ingresses to the store, or updates it if it is already there.
+func (s *Store) AddResourceConfigMap(cm *apiv1.ConfigMap) {
+	for i := range s.ResourceConfigMaps {
+		if s.ResourceConfigMaps[i].GetUID() == cm.GetUID() {
+			s.ResourceConfigMaps[i] = cm
+			return
+		}
+	}
+	s.ResourceConfigMaps = append(s.ResourceConfigMaps, cm)
+}
+
+// PluckResourceConfigMap removes a ConfigMap served by ingresses from the store.
+func (s *Store) PluckResourceConfigMap(cm *apiv1.ConfigMap) {
+	for i := range s.ResourceConfigMaps {
+		if s.ResourceConfigMaps[i].GetUID() == cm.GetUID() {
+			s.ResourceConfigMaps = append(s.ResourceConfigMaps[:i], s.ResourceConfigMaps[i+1:]...)
+			return
+		}
+	}
+}
+
+// GetResourceConfigMap returns a ConfigMap served by ingresses, or nil if it does not exist.
+func (s *Store) GetResourceConfigMap(namespace, name string) *apiv1.ConfigMap {
+	for _, cm := range s.ResourceConfigMaps {
+		if cm.Namespace == namespace && cm.Name == name {
+			return cm
+		}
+	}
+	return nil
+}
+
+// AddService adds a service to the store, or updates it if it is already there.
+func (s *Store) AddService(svc *apiv1.Service) {
+	for i := range s.Services {
+		if s.Services[i].GetUID() == svc.GetUID() {
+			s.Services[i] = svc
+			return
+		}
+	}
+	s.Services = append(s.Services, svc)
+}
+
+// PluckService removes a service from the store.
+func (s *Store) PluckService(svc *apiv1.Service) {
+	for i := range s.Services {
+		if s.Services[i].GetUID() == svc.GetUID() {
+			s.Services = append(s.Services[:i], s.Services[i+1:]...)
+			return
+		}
+	}
+}
+
+// GetService returns a service referenced by ingresses, or nil if it does not exist.
+func (s *Store) GetService(namespace, name string) *apiv1.Service {
+	for _, svc := range s.Services {
+		if svc.Namespace == namespace && svc.Name == name {
+			return svc
+		}
+	}
+	return nil
+}
+
+// AddEndpointSlice adds an endpoint slice to the store, or updates it if it is already there.
+func (s *Store) AddEndpointSlice(slice *discoveryv1.EndpointSlice) {
+	for i := range s.EndpointSlices {
+		if s.EndpointSlices[i].GetUID() == slice.GetUID() {
+			s.EndpointSlices[i] = slice
+			return
+		}
+	}
+	s.EndpointSlices = append(s.EndpointSlices, slice)
+}
+
+// PluckEndpointSlice removes an endpoint slice from the store.
+func (s *Store) PluckEndpointSlice(slice *discoveryv1.EndpointSlice) {
+	for i := range s.EndpointSlices {
+		if s.EndpointSlices[i].GetUID() == slice.GetUID() {
+			s.EndpointSlices = append(s.EndpointSlices[:i], s.EndpointSlices[i+1:]...)
+			return
+		}
+	}
+}
+
+// ServiceEndpointSlices returns the endpoint slices of a service.
+func (s *Store) ServiceEndpointSlices(namespace, name string) []*discoveryv1.EndpointSlice {
+	var slices []*discoveryv1.EndpointSlice
+	for _, slice := range s.EndpointSlices {
+		if slice.Namespace == namespace && slice.Labels[discoveryv1.LabelServiceName] == name {
+			slices = append(slices, slice)
+		}
+	}
+	return slices
+}
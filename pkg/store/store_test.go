@@ -1,8 +1,13 @@
 package store
 
 import (
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/caddyserver/caddy/v2"
+	apiv1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	typev1 "k8s.io/apimachinery/pkg/types"
@@ -198,3 +203,194 @@ func createIngress(uid string) v1.Ingress {
 		},
 	}
 }
+
+func TestParseServiceBackend(t *testing.T) {
+	testCases := []struct {
+		desc              string
+		ref               string
+		expectedNamespace string
+		expectedBackend   *v1.IngressServiceBackend
+		expectedError     string
+	}{
+		{
+			desc:              "port number",
+			ref:               "fallback/web:8080",
+			expectedNamespace: "fallback",
+			expectedBackend:   &v1.IngressServiceBackend{Name: "web", Port: v1.ServiceBackendPort{Number: 8080}},
+		},
+		{
+			desc:              "port name",
+			ref:               "fallback/web:http",
+			expectedNamespace: "fallback",
+			expectedBackend:   &v1.IngressServiceBackend{Name: "web", Port: v1.ServiceBackendPort{Name: "http"}},
+		},
+		{
+			desc:          "missing namespace",
+			ref:           "web:8080",
+			expectedError: `invalid service "web:8080", expected namespace/name:port`,
+		},
+		{
+			desc:          "missing port",
+			ref:           "fallback/web",
+			expectedError: `invalid service "fallback/web", expected namespace/name:port`,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			namespace, backend, err := ParseServiceBackend(tC.ref)
+			if tC.expectedError != "" {
+				if err == nil || err.Error() != tC.expectedError {
+					t.Errorf("expected error %q, got %v", tC.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if namespace != tC.expectedNamespace || !reflect.DeepEqual(backend, tC.expectedBackend) {
+				t.Errorf("got %s %+v, expected %s %+v", namespace, backend, tC.expectedNamespace, tC.expectedBackend)
+			}
+		})
+	}
+}
+
+func TestUpstreamAddressing(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		config     *ConfigMapOptions
+		annotation string
+		expected   string
+	}{
+		{
+			desc:     "default",
+			expected: UpstreamAddressingDNS,
+		},
+		{
+			desc:     "addressing option",
+			config:   &ConfigMapOptions{UpstreamAddressing: UpstreamAddressingClusterIP},
+			expected: UpstreamAddressingClusterIP,
+		},
+		{
+			desc:     "pod endpoints option",
+			config:   &ConfigMapOptions{PodEndpoints: true},
+			expected: UpstreamAddressingEndpoints,
+		},
+		{
+			desc:     "addressing option takes precedence over pod endpoints option",
+			config:   &ConfigMapOptions{PodEndpoints: true, UpstreamAddressing: UpstreamAddressingDNS},
+			expected: UpstreamAddressingDNS,
+		},
+		{
+			desc:       "pod endpoints annotation",
+			config:     &ConfigMapOptions{UpstreamAddressing: UpstreamAddressingClusterIP},
+			annotation: "true",
+			expected:   UpstreamAddressingEndpoints,
+		},
+		{
+			desc:       "pod endpoints annotation opting out of endpoints",
+			config:     &ConfigMapOptions{UpstreamAddressing: UpstreamAddressingEndpoints},
+			annotation: "false",
+			expected:   UpstreamAddressingDNS,
+		},
+		{
+			desc:       "pod endpoints annotation keeping cluster IP",
+			config:     &ConfigMapOptions{UpstreamAddressing: UpstreamAddressingClusterIP},
+			annotation: "false",
+			expected:   UpstreamAddressingClusterIP,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			s := NewStore(Options{}, "", &PodInfo{})
+			s.ConfigMap = tC.config
+
+			ing := &v1.Ingress{}
+			if tC.annotation != "" {
+				ing.Annotations = map[string]string{PodEndpointsAnnotation: tC.annotation}
+			}
+
+			if got := s.UpstreamAddressing(ing); got != tC.expected {
+				t.Errorf("got %q, expected %q", got, tC.expected)
+			}
+		})
+	}
+}
+
+func TestParseConfigMapProxyDefaults(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		data          map[string]string
+		expected      ConfigMapOptions
+		expectedError string
+	}{
+		{
+			desc: "empty chart values",
+			data: map[string]string{"proxyDialTimeout": "", "proxyKeepAliveMaxIdleConns": "0", "proxyMaxBodySize": ""},
+		},
+		{
+			desc: "proxy defaults",
+			data: map[string]string{
+				"proxyDialTimeout":           "5s",
+				"proxyKeepAliveMaxIdleConns": "16",
+				"proxyRequestBuffers":        "unlimited",
+				"proxyMaxBodySize":           "10MB",
+				"proxyFlushInterval":         "-1",
+			},
+			expected: ConfigMapOptions{
+				ProxyDialTimeout:           caddy.Duration(5 * time.Second),
+				ProxyKeepAliveMaxIdleConns: 16,
+				ProxyRequestBuffers:        "unlimited",
+				ProxyMaxBodySize:           "10MB",
+				ProxyFlushInterval:         "-1",
+			},
+		},
+		{
+			desc:          "invalid max body size",
+			data:          map[string]string{"proxyMaxBodySize": "unlimited"},
+			expectedError: `invalid proxyMaxBodySize "unlimited"`,
+		},
+		{
+			desc:          "invalid flush interval",
+			data:          map[string]string{"proxyFlushInterval": "1"},
+			expectedError: `invalid proxyFlushInterval "1"`,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			cfg, err := ParseConfigMap(&apiv1.ConfigMap{Data: tC.data})
+			if tC.expectedError != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tC.expectedError) {
+					t.Errorf("expected error %q, got %v", tC.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(*cfg, tC.expected) {
+				t.Errorf("got %+v, expected %+v", *cfg, tC.expected)
+			}
+		})
+	}
+}
+
+func TestParseConfigMapOnDemandTLS(t *testing.T) {
+	cfg, err := ParseConfigMap(&apiv1.ConfigMap{Data: map[string]string{
+		"onDemandTLS": "true",
+		"onDemandAsk": "http://ask.caddy-system.svc/check",
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.OnDemandTLS || cfg.OnDemandAsk != "http://ask.caddy-system.svc/check" {
+		t.Errorf("got %+v, expected on-demand TLS with its ask endpoint", *cfg)
+	}
+
+	_, err = ParseConfigMap(&apiv1.ConfigMap{Data: map[string]string{"onDemandTLS": "true"}})
+	if err == nil || err.Error() != "onDemandAsk is required with onDemandTLS" {
+		t.Errorf("expected missing onDemandAsk error, got %v", err)
+	}
+}